@@ -4,9 +4,15 @@ import (
 	"bytes"
 	cryptoAes "crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 )
 
+// ErrPadding is returned when a CBC ciphertext has an invalid length or
+// padding, usually because it was tampered with or the key is wrong.
+var ErrPadding = errors.New("aes: invalid padding")
+
 var _ Aes = (*aes)(nil)
 
 type Aes interface {
@@ -59,11 +65,21 @@ func (a *aes) Decrypt(decryptStr string) (string, error) {
 		return "", err
 	}
 
+	if len(decryptBytes) == 0 || len(decryptBytes)%block.BlockSize() != 0 {
+		return "", ErrPadding
+	}
+	if len(a.iv) != block.BlockSize() {
+		return "", errors.New("aes: iv length must equal block size")
+	}
+
 	blockMode := cipher.NewCBCDecrypter(block, []byte(a.iv))
 	decrypted := make([]byte, len(decryptBytes))
 
 	blockMode.CryptBlocks(decrypted, decryptBytes)
-	decrypted = pkcs5UnPadding(decrypted)
+	decrypted, err = pkcs5UnPadding(decrypted, block.BlockSize())
+	if err != nil {
+		return "", err
+	}
 	return string(decrypted), nil
 }
 
@@ -73,8 +89,24 @@ func pkcs5Padding(cipherText []byte, blockSize int) []byte {
 	return append(cipherText, padText...)
 }
 
-func pkcs5UnPadding(decrypted []byte) []byte {
+// pkcs5UnPadding checks every padding byte instead of trusting the last
+// one, and does so in constant time.
+func pkcs5UnPadding(decrypted []byte, blockSize int) ([]byte, error) {
 	length := len(decrypted)
+	if length == 0 || length%blockSize != 0 {
+		return nil, ErrPadding
+	}
+
 	unPadding := int(decrypted[length-1])
-	return decrypted[:(length - unPadding)]
+	good := subtle.ConstantTimeLessOrEq(1, unPadding) & subtle.ConstantTimeLessOrEq(unPadding, blockSize)
+	for i := 1; i <= blockSize; i++ {
+		// bytes within the padding must all equal unPadding
+		inPadding := subtle.ConstantTimeLessOrEq(i, unPadding)
+		same := subtle.ConstantTimeByteEq(decrypted[length-i], byte(unPadding))
+		good &= same | (inPadding ^ 1)
+	}
+	if good != 1 {
+		return nil, ErrPadding
+	}
+	return decrypted[:(length - unPadding)], nil
 }
@@ -23,3 +23,20 @@ func BenchmarkEncryptAndDecrypt(b *testing.B) {
 		aes.Decrypt(encryptString)
 	}
 }
+
+func TestDecryptTampered(t *testing.T) {
+	a := New(key, iv)
+	enc, err := a.Encrypt("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec, err := a.Decrypt(enc); err != nil || dec != "123456" {
+		t.Fatalf("Decrypt() = %q, %v", dec, err)
+	}
+
+	for _, s := range []string{"", "AAAA", "GO-ri84zevE-z1biJwfQPw==", enc[:len(enc)-4] + "AA=="} {
+		if dec, err := a.Decrypt(s); err == nil {
+			t.Errorf("Decrypt(%q) = %q, want error", s, dec)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package aes
+
+import (
+	cryptoAes "crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// VersionGCM is the version byte of the AES-GCM envelope:
+//
+//	version (1) | key ID length (1) | key ID | nonce (12) | ciphertext | tag (16)
+//
+// The version and the key ID are authenticated along with the associated
+// data, so they cannot be swapped.
+const VersionGCM byte = 1
+
+const gcmNonceSize = 12
+
+var (
+	// ErrEnvelope is returned for data that is not a well formed envelope.
+	ErrEnvelope = errors.New("aes: malformed envelope")
+
+	// ErrUnknownKey is returned for envelopes sealed with another key.
+	ErrUnknownKey = errors.New("aes: unknown key id")
+
+	// ErrAuth is returned when an envelope fails authentication: it was
+	// tampered with, or the key or the associated data differ.
+	ErrAuth = errors.New("aes: message authentication failed")
+)
+
+// Envelope is a parsed AES-GCM envelope.
+type Envelope struct {
+	Version    byte
+	KeyID      string
+	Nonce      []byte
+	Ciphertext []byte // including the tag
+
+	header []byte
+}
+
+// ParseEnvelope splits data into its fields without decrypting it.
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if len(data) < 2 || data[0] != VersionGCM {
+		return nil, ErrEnvelope
+	}
+	idLen := int(data[1])
+	if len(data) < 2+idLen+gcmNonceSize+16 {
+		return nil, ErrEnvelope
+	}
+
+	rest := data[2+idLen:]
+	return &Envelope{
+		Version:    data[0],
+		KeyID:      string(data[2 : 2+idLen]),
+		Nonce:      rest[:gcmNonceSize],
+		Ciphertext: rest[gcmNonceSize:],
+		header:     data[:2+idLen],
+	}, nil
+}
+
+var _ Aes = (*GCM)(nil)
+
+// GCM encrypts with AES-GCM and a random nonce per message. Through its
+// Encrypt and Decrypt methods it is a drop-in replacement of New, and with
+// WithLegacy it still decrypts the CBC ciphertexts of New.
+//
+// A random 96-bit nonce is safe for about 2^32 messages per key; rotate the
+// key well before that.
+type GCM struct {
+	keyID  string
+	aead   cipher.AEAD
+	legacy Aes
+}
+
+// GCMOption configures a GCM.
+type GCMOption func(*GCM)
+
+// WithLegacy makes Decrypt fall back to legacy, usually New(key, iv), for
+// data that is not a GCM envelope.
+func WithLegacy(legacy Aes) GCMOption {
+	return func(g *GCM) { g.legacy = legacy }
+}
+
+// NewGCM creates a GCM from a 16, 24 or 32 byte key. keyID, at most 255
+// bytes, is written into every envelope to find the key when decrypting.
+func NewGCM(keyID string, key []byte, opts ...GCMOption) (*GCM, error) {
+	if len(keyID) > 255 {
+		return nil, errors.New("aes: key id is longer than 255 bytes")
+	}
+	block, err := cryptoAes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GCM{keyID: keyID, aead: aead}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, nil
+}
+
+func (g *GCM) i() {}
+
+// KeyID returns the key ID written into the envelopes.
+func (g *GCM) KeyID() string {
+	return g.keyID
+}
+
+// Seal encrypts plaintext into an envelope. additionalData, which may be
+// nil, is authenticated but not encrypted; Open needs the same value.
+func (g *GCM) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	header := make([]byte, 2+len(g.keyID), 2+len(g.keyID)+gcmNonceSize+len(plaintext)+g.aead.Overhead())
+	header[0] = VersionGCM
+	header[1] = byte(len(g.keyID))
+	copy(header[2:], g.keyID)
+
+	out := header[:len(header)+gcmNonceSize]
+	nonce := out[len(header):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("aes: %w", err)
+	}
+	return g.aead.Seal(out, nonce, plaintext, g.aad(header, additionalData)), nil
+}
+
+// Open decrypts an envelope made by Seal.
+func (g *GCM) Open(envelope, additionalData []byte) ([]byte, error) {
+	env, err := ParseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return g.open(env, additionalData)
+}
+
+func (g *GCM) open(env *Envelope, additionalData []byte) ([]byte, error) {
+	if env.KeyID != g.keyID {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, env.KeyID)
+	}
+	plaintext, err := g.aead.Open(nil, env.Nonce, env.Ciphertext, g.aad(env.header, additionalData))
+	if err != nil {
+		return nil, ErrAuth
+	}
+	return plaintext, nil
+}
+
+func (g *GCM) aad(header, additionalData []byte) []byte {
+	if len(additionalData) == 0 {
+		return header
+	}
+	return append(header[:len(header):len(header)], additionalData...)
+}
+
+// Encrypt 加密，返回 base64 编码的信封
+func (g *GCM) Encrypt(encryptStr string) (string, error) {
+	envelope, err := g.Seal([]byte(encryptStr), nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(envelope), nil
+}
+
+// Decrypt 解密，非 GCM 信封时使用 WithLegacy 设置的旧算法
+func (g *GCM) Decrypt(decryptStr string) (string, error) {
+	return decryptEnvelope(decryptStr, g.legacy, func(env *Envelope) ([]byte, error) {
+		return g.open(env, nil)
+	})
+}
+
+// decryptEnvelope decodes and opens an envelope, falling back to legacy for
+// anything that is not an envelope or fails to open.
+func decryptEnvelope(s string, legacy Aes, open func(*Envelope) ([]byte, error)) (string, error) {
+	data, err := base64.URLEncoding.DecodeString(s)
+	var env *Envelope
+	if err == nil {
+		env, err = ParseEnvelope(data)
+	}
+
+	var plaintext []byte
+	if err == nil {
+		plaintext, err = open(env)
+	}
+	if err != nil {
+		// a legacy ciphertext may look like an envelope by chance
+		if legacy != nil {
+			if s, legacyErr := legacy.Decrypt(s); legacyErr == nil || env == nil {
+				return s, legacyErr
+			}
+		}
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// LegacyFunc adapts a decryption function, such as utils.DeTxtByAesWithErr
+// with its key or the Decrypt of a utils.Cipher with the decoding of its
+// data, to the Aes interface for WithLegacy and Migrate. Its Encrypt fails.
+type LegacyFunc func(decryptStr string) (string, error)
+
+func (f LegacyFunc) i() {}
+
+func (f LegacyFunc) Encrypt(string) (string, error) {
+	return "", errors.New("aes: legacy ciphers only decrypt")
+}
+
+func (f LegacyFunc) Decrypt(decryptStr string) (string, error) {
+	return f(decryptStr)
+}
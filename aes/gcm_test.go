@@ -0,0 +1,94 @@
+package aes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var gcmKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestGCM(t *testing.T) {
+	g, err := NewGCM("k1", gcmKey)
+	assert.NoError(t, err)
+
+	env, err := g.Seal([]byte("hello"), []byte("user:42"))
+	assert.NoError(t, err)
+	assert.Equal(t, VersionGCM, env[0])
+
+	parsed, err := ParseEnvelope(env)
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", parsed.KeyID)
+	assert.Len(t, parsed.Nonce, 12)
+	assert.Len(t, parsed.Ciphertext, 5+16)
+
+	plain, err := g.Open(env, []byte("user:42"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(plain))
+
+	// random nonces
+	again, _ := g.Seal([]byte("hello"), []byte("user:42"))
+	assert.NotEqual(t, env, again)
+
+	_, err = g.Open(env, []byte("user:43"))
+	assert.ErrorIs(t, err, ErrAuth)
+
+	for i := range env {
+		tampered := bytes.Clone(env)
+		tampered[i] ^= 0x80
+		_, err = g.Open(tampered, []byte("user:42"))
+		assert.Error(t, err, "byte %d", i)
+	}
+
+	other, _ := NewGCM("k2", gcmKey)
+	_, err = other.Open(env, []byte("user:42"))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = g.Open(env[:20], nil)
+	assert.ErrorIs(t, err, ErrEnvelope)
+
+	_, err = NewGCM("k", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestGCMLegacy(t *testing.T) {
+	cbc := New(key, iv)
+	g, _ := NewGCM("k1", gcmKey, WithLegacy(cbc))
+
+	enc, err := g.Encrypt("123456")
+	assert.NoError(t, err)
+	dec, err := g.Decrypt(enc)
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", dec)
+
+	// CBC ciphertexts from before the migration
+	for _, s := range []string{"123456", "", "a longer message spanning several blocks"} {
+		old, _ := cbc.Encrypt(s)
+		dec, err = g.Decrypt(old)
+		assert.NoError(t, err)
+		assert.Equal(t, s, dec)
+	}
+
+	strict, _ := NewGCM("k1", gcmKey)
+	old, _ := cbc.Encrypt("123456")
+	_, err = strict.Decrypt(old)
+	assert.Error(t, err)
+
+	raw, _ := base64.URLEncoding.DecodeString(enc)
+	raw[len(raw)-1] ^= 1
+	_, err = g.Decrypt(base64.URLEncoding.EncodeToString(raw))
+	assert.ErrorIs(t, err, ErrAuth)
+}
+
+func BenchmarkGCM(b *testing.B) {
+	g, _ := NewGCM("k1", gcmKey)
+	msg := bytes.Repeat([]byte("x"), 1024)
+	b.SetBytes(int64(len(msg)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		env, _ := g.Seal(msg, nil)
+		_, _ = g.Open(env, nil)
+	}
+}
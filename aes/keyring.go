@@ -0,0 +1,286 @@
+package aes
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+	"sync"
+)
+
+// ErrNoActiveKey is returned when encrypting with a keyring without an
+// active key.
+var ErrNoActiveKey = errors.New("aes: keyring has no active key")
+
+// KeyVersion is a named version of a key.
+type KeyVersion struct {
+	ID  string
+	Key []byte
+}
+
+// KeySource loads key versions, see EnvSource, FileSource and KMSSource.
+// active is the ID of the active key, or empty when the source does not
+// name one.
+type KeySource interface {
+	Load() (versions []KeyVersion, active string, err error)
+}
+
+var _ Aes = (*Keyring)(nil)
+
+// Keyring holds named key versions. It encrypts with the active key and
+// decrypts with whichever key the envelope names, so keys can be rotated
+// without re-encrypting everything at once. It is safe for concurrent use.
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[string]*GCM
+	active string
+	legacy Aes
+}
+
+// KeyringOption configures a Keyring.
+type KeyringOption func(*Keyring)
+
+// WithKeyringLegacy makes Decrypt fall back to legacy for data that is not
+// an envelope, see WithLegacy.
+func WithKeyringLegacy(legacy Aes) KeyringOption {
+	return func(k *Keyring) { k.legacy = legacy }
+}
+
+// NewKeyring creates an empty keyring.
+func NewKeyring(opts ...KeyringOption) *Keyring {
+	k := &Keyring{keys: map[string]*GCM{}}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
+}
+
+// LoadKeyring creates a keyring from sources. When several sources name
+// an active key, the last one wins. The active key may come from another
+// source, but it must be in the keyring: a missing or misspelled ID is an
+// error rather than a silent fallback to another key.
+func LoadKeyring(sources []KeySource, opts ...KeyringOption) (*Keyring, error) {
+	k := NewKeyring(opts...)
+	active := ""
+	for _, src := range sources {
+		versions, id, err := src.Load()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			if err = k.Add(v.ID, v.Key); err != nil {
+				return nil, err
+			}
+		}
+		if id != "" {
+			active = id
+		}
+	}
+	if active == "" {
+		return nil, ErrNoActiveKey
+	}
+	if err := k.SetActive(active); err != nil {
+		return nil, fmt.Errorf("%w: %q is not loaded", ErrNoActiveKey, active)
+	}
+	return k, nil
+}
+
+// Add adds a key version. Call SetActive to encrypt with it.
+func (k *Keyring) Add(id string, key []byte) error {
+	g, err := NewGCM(id, key)
+	if err != nil {
+		return fmt.Errorf("aes: key %q: %w", id, err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("aes: duplicate key id %q", id)
+	}
+	k.keys[id] = g
+	return nil
+}
+
+// SetActive makes id the key used for encryption.
+func (k *Keyring) SetActive(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	k.active = id
+	return nil
+}
+
+// Remove removes a retired key version. The active key cannot be removed.
+func (k *Keyring) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.active {
+		return errors.New("aes: cannot remove the active key")
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Active returns the ID of the active key.
+func (k *Keyring) Active() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// IDs returns the sorted IDs of all key versions.
+func (k *Keyring) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (k *Keyring) activeKey() (*GCM, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	g, ok := k.keys[k.active]
+	if !ok {
+		return nil, ErrNoActiveKey
+	}
+	return g, nil
+}
+
+func (k *Keyring) key(id string) (*GCM, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	g, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	return g, nil
+}
+
+func (k *Keyring) i() {}
+
+// Seal encrypts plaintext with the active key, see GCM.Seal.
+func (k *Keyring) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	g, err := k.activeKey()
+	if err != nil {
+		return nil, err
+	}
+	return g.Seal(plaintext, additionalData)
+}
+
+// Open decrypts an envelope with the key it names.
+func (k *Keyring) Open(envelope, additionalData []byte) ([]byte, error) {
+	env, err := ParseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return k.open(env, additionalData)
+}
+
+func (k *Keyring) open(env *Envelope, additionalData []byte) ([]byte, error) {
+	g, err := k.key(env.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return g.open(env, additionalData)
+}
+
+// Encrypt 使用当前密钥加密
+func (k *Keyring) Encrypt(encryptStr string) (string, error) {
+	g, err := k.activeKey()
+	if err != nil {
+		return "", err
+	}
+	return g.Encrypt(encryptStr)
+}
+
+// Decrypt 使用信封中的密钥解密，非信封时使用旧算法
+func (k *Keyring) Decrypt(decryptStr string) (string, error) {
+	return decryptEnvelope(decryptStr, k.legacy, func(env *Envelope) ([]byte, error) {
+		return k.open(env, nil)
+	})
+}
+
+// Reencrypt decrypts an Encrypt or legacy ciphertext and encrypts it again
+// with the active key. It reports false, and returns the ciphertext as is,
+// when it already uses the active key.
+func (k *Keyring) Reencrypt(ciphertext string) (string, bool, error) {
+	if k.IsCurrent(ciphertext) {
+		return ciphertext, false, nil
+	}
+	plaintext, err := k.Decrypt(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	ciphertext, err = k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return ciphertext, true, nil
+}
+
+// IsCurrent reports whether ciphertext is an envelope of the active key.
+func (k *Keyring) IsCurrent(ciphertext string) bool {
+	env, err := parseEnvelopeString(ciphertext)
+	return err == nil && env.KeyID == k.Active()
+}
+
+// ReencryptStats counts the records seen by ReencryptAll.
+type ReencryptStats struct {
+	Total   int
+	Rotated int
+	Current int
+	Failed  int
+}
+
+// ReencryptAll migrates stored ciphertexts to the active key. records
+// yields the ID and ciphertext of each record, typically from a database
+// cursor; save is called with the new ciphertext of the records that were
+// not encrypted with the active key yet. Records that fail to decrypt are
+// counted and their errors joined, a failing save stops the migration.
+// Running it again resumes where it stopped.
+func (k *Keyring) ReencryptAll(ctx context.Context, records iter.Seq2[string, string],
+	save func(id, ciphertext string) error) (ReencryptStats, error) {
+	var (
+		stats ReencryptStats
+		errs  []error
+	)
+	for id, ciphertext := range records {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		stats.Total++
+		updated, rotated, err := k.Reencrypt(ciphertext)
+		switch {
+		case err != nil:
+			stats.Failed++
+			errs = append(errs, fmt.Errorf("record %s: %w", id, err))
+			continue
+		case !rotated:
+			stats.Current++
+			continue
+		}
+
+		if err = save(id, updated); err != nil {
+			return stats, fmt.Errorf("aes: save record %s: %w", id, err)
+		}
+		stats.Rotated++
+	}
+	return stats, errors.Join(errs...)
+}
+
+func parseEnvelopeString(s string) (*Envelope, error) {
+	data, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrEnvelope
+	}
+	return ParseEnvelope(data)
+}
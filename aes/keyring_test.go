@@ -0,0 +1,192 @@
+package aes
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/v-mars/library/utils"
+)
+
+var (
+	key1 = []byte("11111111111111111111111111111111")
+	key2 = []byte("22222222222222222222222222222222")
+)
+
+func TestKeyringRotation(t *testing.T) {
+	k := NewKeyring()
+	assert.NoError(t, k.Add("v1", key1))
+	assert.Equal(t, "", k.Active())
+	_, err := k.Encrypt("secret")
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+	assert.NoError(t, k.SetActive("v1"))
+
+	old, err := k.Encrypt("secret")
+	assert.NoError(t, err)
+	assert.True(t, k.IsCurrent(old))
+
+	assert.NoError(t, k.Add("v2", key2))
+	assert.NoError(t, k.SetActive("v2"))
+	assert.Equal(t, []string{"v1", "v2"}, k.IDs())
+	assert.False(t, k.IsCurrent(old))
+
+	// old ciphertexts still decrypt
+	dec, err := k.Decrypt(old)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", dec)
+
+	updated, rotated, err := k.Reencrypt(old)
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+	env, _ := parseEnvelopeString(updated)
+	assert.Equal(t, "v2", env.KeyID)
+
+	same, rotated, err := k.Reencrypt(updated)
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+	assert.Equal(t, updated, same)
+
+	assert.Error(t, k.Remove("v2"))
+	assert.NoError(t, k.Remove("v1"))
+	_, err = k.Decrypt(old)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	assert.Error(t, k.Add("v2", key1))
+	assert.ErrorIs(t, k.SetActive("v9"), ErrUnknownKey)
+	_, err = NewKeyring().Encrypt("x")
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	sealed, err := k.Seal([]byte("raw"), []byte("ad"))
+	assert.NoError(t, err)
+	plain, err := k.Open(sealed, []byte("ad"))
+	assert.NoError(t, err)
+	assert.Equal(t, "raw", string(plain))
+}
+
+func TestKeyringReencryptAll(t *testing.T) {
+	cbc := New(key, iv)
+	k := NewKeyring(WithKeyringLegacy(cbc))
+	_ = k.Add("v1", key1)
+	_ = k.SetActive("v1")
+
+	store := map[string]string{}
+	store["a"], _ = cbc.Encrypt("password-a")
+	store["b"], _ = k.Encrypt("password-b")
+	_ = k.Add("v2", key2)
+	_ = k.SetActive("v2")
+	store["c"], _ = k.Encrypt("password-c")
+	store["d"] = "garbage"
+
+	stats, err := k.ReencryptAll(context.Background(), maps.All(maps.Clone(store)), func(id, ciphertext string) error {
+		store[id] = ciphertext
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "record d")
+	assert.Equal(t, ReencryptStats{Total: 4, Rotated: 2, Current: 1, Failed: 1}, stats)
+
+	for id, want := range map[string]string{"a": "password-a", "b": "password-b", "c": "password-c"} {
+		assert.True(t, k.IsCurrent(store[id]), id)
+		dec, err := k.Decrypt(store[id])
+		assert.NoError(t, err)
+		assert.Equal(t, want, dec)
+	}
+
+	boom := errors.New("boom")
+	_ = k.Add("v3", key1)
+	_ = k.SetActive("v3")
+	_, err = k.ReencryptAll(context.Background(), maps.All(maps.Clone(store)), func(string, string) error { return boom })
+	assert.ErrorIs(t, err, boom)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = k.ReencryptAll(ctx, maps.All(store), nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestKeyringUtilsLegacy(t *testing.T) {
+	const pwdKey = "DIS**#KKKDJJSKDI"
+	legacy := LegacyFunc(func(s string) (string, error) { return utils.DeTxtByAesWithErr(s, pwdKey) })
+	k := NewKeyring(WithKeyringLegacy(legacy))
+	_ = k.Add("v1", key1)
+	_ = k.SetActive("v1")
+
+	old := utils.EnTxtByAes("db-password", pwdKey)
+	dec, err := k.Decrypt(old)
+	assert.NoError(t, err)
+	assert.Equal(t, "db-password", dec)
+
+	_, err = legacy.Encrypt("x")
+	assert.Error(t, err)
+}
+
+func TestKeySources(t *testing.T) {
+	t.Setenv("TEST_AES_V1", "base64:"+base64.StdEncoding.EncodeToString(key1))
+	t.Setenv("TEST_AES_V2", string(key2))
+	t.Setenv("TEST_AES_ACTIVE", "V2")
+	t.Setenv("TEST_AES_MODE", "gcm") // not a key
+
+	k, err := LoadKeyring([]KeySource{EnvSource("TEST_AES")})
+	assert.NoError(t, err)
+	assert.Equal(t, "V2", k.Active())
+	assert.Equal(t, []string{"V1", "V2"}, k.IDs())
+
+	kms, err := NewLocalKMS(key1)
+	assert.NoError(t, err)
+	dataKey, wrapped, err := kms.GenerateDataKey("kms1")
+	assert.NoError(t, err)
+	assert.Len(t, dataKey, 32)
+	_, err = kms.Unwrap("kms2", wrapped)
+	assert.ErrorIs(t, err, ErrAuth)
+
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("active: f2\nkeys:\n  f1: hex:"+
+		"3131313131313131313131313131313131313131313131313131313131313131\n  f2: 2222222222222222\n"), 0o600))
+
+	k, err = LoadKeyring([]KeySource{
+		FileSource(path),
+		KMSSource{KMS: kms, Keys: map[string][]byte{"kms1": wrapped}, Active: "kms1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "kms1", k.Active())
+	assert.Equal(t, []string{"f1", "f2", "kms1"}, k.IDs())
+
+	_, err = LoadKeyring([]KeySource{FileSource(filepath.Join(t.TempDir(), "missing"))})
+	assert.Error(t, err)
+	_, err = LoadKeyring([]KeySource{EnvSource("TEST_AES_NONE")})
+	assert.Error(t, err)
+}
+
+func TestLoadKeyringMissingActive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	keys := "keys:\n  v1: 1111111111111111\n  v2: 2222222222222222\n  v3: 3333333333333333\n"
+
+	// a misspelled active key never falls back to another key
+	assert.NoError(t, os.WriteFile(path, []byte("active: v9\n"+keys), 0o600))
+	for range 20 {
+		_, err := LoadKeyring([]KeySource{FileSource(path)})
+		assert.ErrorIs(t, err, ErrNoActiveKey)
+		assert.ErrorContains(t, err, `"v9"`)
+	}
+
+	assert.NoError(t, os.WriteFile(path, []byte(keys), 0o600))
+	_, err := LoadKeyring([]KeySource{FileSource(path)})
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	// the active key may come from another source
+	kms, _ := NewLocalKMS(key1)
+	_, wrapped, _ := kms.GenerateDataKey("k1")
+	assert.NoError(t, os.WriteFile(path, []byte("active: k1\n"+keys), 0o600))
+	k, err := LoadKeyring([]KeySource{
+		FileSource(path),
+		KMSSource{KMS: kms, Keys: map[string][]byte{"k1": wrapped}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", k.Active())
+}
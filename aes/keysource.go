@@ -0,0 +1,166 @@
+package aes
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseKey decodes a key from configuration: "base64:..." and "hex:..."
+// are decoded, anything else is used as is, like the keys given to New.
+func ParseKey(s string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(s, "base64:"):
+		return base64.StdEncoding.DecodeString(s[len("base64:"):])
+	case strings.HasPrefix(s, "hex:"):
+		return hex.DecodeString(s[len("hex:"):])
+	}
+	return []byte(s), nil
+}
+
+// EnvSource loads the keys from the environment variables PREFIX_<ID>, with
+// the active key ID in PREFIX_ACTIVE. For example with prefix "APP_AES":
+//
+//	APP_AES_2024=base64:...
+//	APP_AES_2025=base64:...
+//	APP_AES_ACTIVE=2025
+//
+// IDs are versions: a digit, or "v" or "V" and a digit, followed by
+// letters, digits, ".", "-" or "_", such as 2025, v2 or V2025_01. Other
+// variables sharing the prefix, such as APP_AES_MODE, are skipped.
+type EnvSource string
+
+// envKeyID matches the key IDs of EnvSource.
+var envKeyID = regexp.MustCompile(`^[vV]?[0-9][0-9A-Za-z._-]*$`)
+
+func (prefix EnvSource) Load() ([]KeyVersion, string, error) {
+	p := string(prefix) + "_"
+	active := os.Getenv(p + "ACTIVE")
+
+	var versions []KeyVersion
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		id, ok := strings.CutPrefix(name, p)
+		if !ok || len(id) > 255 || !envKeyID.MatchString(id) {
+			continue
+		}
+		key, err := ParseKey(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("aes: env %s: %w", name, err)
+		}
+		versions = append(versions, KeyVersion{ID: id, Key: key})
+	}
+	if len(versions) == 0 {
+		return nil, "", fmt.Errorf("aes: no keys in env %s*", p)
+	}
+	return versions, active, nil
+}
+
+// FileSource loads the keys from a YAML or JSON file:
+//
+//	active: v2
+//	keys:
+//	  v1: base64:...
+//	  v2: base64:...
+type FileSource string
+
+func (path FileSource) Load() ([]KeyVersion, string, error) {
+	data, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, "", fmt.Errorf("aes: %w", err)
+	}
+
+	var file struct {
+		Active string            `yaml:"active"`
+		Keys   map[string]string `yaml:"keys"`
+	}
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("aes: %s: %w", path, err)
+	}
+
+	versions := make([]KeyVersion, 0, len(file.Keys))
+	for id, value := range file.Keys {
+		key, err := ParseKey(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("aes: %s: key %q: %w", path, id, err)
+		}
+		versions = append(versions, KeyVersion{ID: id, Key: key})
+	}
+	return versions, file.Active, nil
+}
+
+// KMS unwraps data keys encrypted by a key management service.
+type KMS interface {
+	Unwrap(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// KMSSource loads data keys that are stored wrapped by a KMS, so the
+// configuration never holds a usable key.
+type KMSSource struct {
+	KMS    KMS
+	Keys   map[string][]byte // wrapped keys by key ID
+	Active string
+}
+
+func (s KMSSource) Load() ([]KeyVersion, string, error) {
+	versions := make([]KeyVersion, 0, len(s.Keys))
+	for id, wrapped := range s.Keys {
+		key, err := s.KMS.Unwrap(id, wrapped)
+		if err != nil {
+			return nil, "", fmt.Errorf("aes: unwrap key %q: %w", id, err)
+		}
+		versions = append(versions, KeyVersion{ID: id, Key: key})
+	}
+	return versions, s.Active, nil
+}
+
+var _ KMS = (*LocalKMS)(nil)
+
+// LocalKMS is a KMS stand-in for development and tests: the data keys are
+// wrapped with a master key held in memory.
+type LocalKMS struct {
+	master *GCM
+}
+
+// NewLocalKMS creates a LocalKMS from a 16, 24 or 32 byte master key.
+func NewLocalKMS(master []byte) (*LocalKMS, error) {
+	g, err := NewGCM("kms", master)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKMS{master: g}, nil
+}
+
+// GenerateDataKey returns a new random 32 byte data key and its wrapped
+// form, which is bound to keyID.
+func (k *LocalKMS) GenerateDataKey(keyID string) (key, wrapped []byte, err error) {
+	key = make([]byte, 32)
+	if _, err = rand.Read(key); err != nil {
+		return nil, nil, fmt.Errorf("aes: %w", err)
+	}
+	wrapped, err = k.Wrap(keyID, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, wrapped, nil
+}
+
+// Wrap encrypts a data key for keyID.
+func (k *LocalKMS) Wrap(keyID string, key []byte) ([]byte, error) {
+	if keyID == "" {
+		return nil, errors.New("aes: empty key id")
+	}
+	return k.master.Seal(key, []byte(keyID))
+}
+
+// Unwrap decrypts a data key wrapped for keyID.
+func (k *LocalKMS) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	return k.master.Open(wrapped, []byte(keyID))
+}
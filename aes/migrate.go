@@ -0,0 +1,58 @@
+package aes
+
+import (
+	"encoding/base64"
+	"errors"
+)
+
+// ErrNoLegacyCipher is returned by Migrate when no legacy cipher decrypts
+// the data.
+var ErrNoLegacyCipher = errors.New("aes: no legacy cipher decrypts the data")
+
+// Sealer seals and opens envelopes. GCM and Keyring implement it.
+type Sealer interface {
+	Seal(plaintext, additionalData []byte) ([]byte, error)
+	Open(envelope, additionalData []byte) ([]byte, error)
+}
+
+var (
+	_ Sealer = (*GCM)(nil)
+	_ Sealer = (*Keyring)(nil)
+)
+
+// Migrate moves data encrypted with a legacy cipher, such as New or the CBC
+// helpers of utils wrapped in a LegacyFunc, to AES-GCM. It returns
+// ciphertext as is and false when it already opens with dst, otherwise the
+// plaintext of the first legacy cipher that decrypts it, sealed with dst
+// and encoded like GCM.Encrypt, and true. additionalData is the one the
+// migrated envelopes are sealed with, and must be the one of the data
+// already migrated, or it is not recognised as such.
+//
+// Legacy ciphertexts are not authenticated: a wrong key passes the padding
+// check once in about 256 tries and yields garbage. Pass only the ciphers
+// the data may have been encrypted with, most likely first, and check the
+// migrated plaintext when it has a known shape.
+func Migrate(dst Sealer, ciphertext string, additionalData []byte, legacy ...Aes) (string, bool, error) {
+	// legacy data may start like an envelope, so only a successful Open
+	// tells them apart
+	if data, err := base64.URLEncoding.DecodeString(ciphertext); err == nil {
+		if _, err = ParseEnvelope(data); err == nil {
+			if _, err = dst.Open(data, additionalData); err == nil {
+				return ciphertext, false, nil
+			}
+		}
+	}
+
+	for _, c := range legacy {
+		plaintext, err := c.Decrypt(ciphertext)
+		if err != nil {
+			continue
+		}
+		sealed, err := dst.Seal([]byte(plaintext), additionalData)
+		if err != nil {
+			return "", false, err
+		}
+		return base64.URLEncoding.EncodeToString(sealed), true, nil
+	}
+	return "", false, ErrNoLegacyCipher
+}
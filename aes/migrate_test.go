@@ -0,0 +1,88 @@
+package aes
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/v-mars/library/utils"
+)
+
+// hexLegacy reads the hex encoded ciphertexts of a utils cipher.
+func hexLegacy(c utils.Cipher) LegacyFunc {
+	return func(s string) (string, error) {
+		data, err := hex.DecodeString(s)
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := c.Decrypt(data)
+		return string(plaintext), err
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	const pwdKey = "lInkbook1qazEWSP"
+	desKey := []byte("12345678")
+	tdesKey := []byte("12345678876543211aaaaaaa")
+	des, _ := utils.NewDESCipher(desKey)
+	tdes, _ := utils.NewTripleDESCipher(tdesKey)
+	cbc := New(pwdKey, pwdKey)
+	legacy := []Aes{
+		cbc,
+		LegacyFunc(func(s string) (string, error) { return utils.DeTxtByAesWithErr(s, pwdKey) }),
+		hexLegacy(tdes),
+		hexLegacy(des),
+	}
+
+	k := NewKeyring()
+	assert.NoError(t, k.Add("v1", key1))
+	assert.NoError(t, k.SetActive("v1"))
+
+	aesOld, _ := cbc.Encrypt("aes secret")
+	for want, old := range map[string]string{
+		"des secret":  hex.EncodeToString(utils.EncryptDES([]byte("des secret"), desKey)),
+		"3des secret": hex.EncodeToString(utils.Encrypt3DES([]byte("3des secret"), tdesKey)),
+		"txt secret":  utils.EnTxtByAes("txt secret", pwdKey),
+		"aes secret":  aesOld,
+	} {
+		migrated, ok, err := Migrate(k, old, nil, legacy...)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		pt, err := k.Decrypt(migrated)
+		assert.NoError(t, err)
+		assert.Equal(t, want, pt)
+
+		// migrating twice is a no-op
+		again, ok, err := Migrate(k, migrated, nil, legacy...)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, migrated, again)
+	}
+
+	_, _, err := Migrate(k, "not a ciphertext", nil, hexLegacy(des))
+	assert.ErrorIs(t, err, ErrNoLegacyCipher)
+	_, _, err = Migrate(k, aesOld, nil)
+	assert.ErrorIs(t, err, ErrNoLegacyCipher)
+
+	// an envelope of another key is not mistaken for migrated data
+	g, _ := NewGCM("other", key2)
+	foreign, _ := g.Encrypt("x")
+	_, _, err = Migrate(k, foreign, nil, hexLegacy(des))
+	assert.ErrorIs(t, err, ErrNoLegacyCipher)
+
+	// data bound to a record is migrated and recognised with its additional data
+	aad := []byte("users/42")
+	migrated, ok, err := Migrate(k, aesOld, aad, legacy...)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	again, ok, err := Migrate(k, migrated, aad, legacy...)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, migrated, again)
+	env, _ := base64.URLEncoding.DecodeString(migrated)
+	pt, err := k.Open(env, aad)
+	assert.NoError(t, err)
+	assert.Equal(t, "aes secret", string(pt))
+}
@@ -0,0 +1,355 @@
+package aes
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// VersionStream is the version byte of the streaming format:
+//
+//	version (1) | key ID length (1) | key ID | chunk size (4) | nonce prefix (8)
+//
+// followed by chunks of chunk size bytes of plaintext sealed with AES-GCM.
+// The nonce of chunk i is the prefix followed by i, and the header and a
+// final flag are authenticated with every chunk, so reordered, dropped,
+// truncated or appended chunks all fail to decrypt.
+const VersionStream byte = 2
+
+const (
+	// DefaultChunkSize is the plaintext size of the chunks.
+	DefaultChunkSize = 64 << 10
+
+	maxChunkSize       = 16 << 20
+	streamPrefixSize   = 8
+	streamTagSize      = 16
+	streamFinal        = 1
+	streamIntermediate = 0
+)
+
+// ErrTruncated is returned when a stream ends before its final chunk.
+var ErrTruncated = errors.New("aes: stream truncated")
+
+// StreamCipher encrypts and decrypts streams. GCM and Keyring implement it.
+type StreamCipher interface {
+	NewEncryptWriter(w io.Writer) (io.WriteCloser, error)
+	NewDecryptReader(r io.Reader) (io.Reader, error)
+}
+
+var (
+	_ StreamCipher = (*GCM)(nil)
+	_ StreamCipher = (*Keyring)(nil)
+)
+
+// NewEncryptWriter returns a writer encrypting to w in chunks of
+// DefaultChunkSize, so memory use does not depend on the size of the data.
+// Close must be called to write the final chunk; it does not close w.
+func (g *GCM) NewEncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	header := make([]byte, 2+len(g.keyID)+4+streamPrefixSize)
+	header[0] = VersionStream
+	header[1] = byte(len(g.keyID))
+	copy(header[2:], g.keyID)
+	binary.BigEndian.PutUint32(header[2+len(g.keyID):], DefaultChunkSize)
+	if _, err := rand.Read(header[len(header)-streamPrefixSize:]); err != nil {
+		return nil, fmt.Errorf("aes: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:      w,
+		aead:   g.aead,
+		stream: newStreamState(header, DefaultChunkSize),
+		buf:    make([]byte, 0, DefaultChunkSize),
+	}, nil
+}
+
+// NewDecryptReader returns a reader decrypting a stream of
+// NewEncryptWriter. Every chunk is authenticated before it is returned, and
+// the last Read returns ErrTruncated instead of io.EOF for an incomplete
+// stream: do not trust the output until io.EOF.
+func (g *GCM) NewDecryptReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	keyID, header, chunkSize, err := readStreamHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if keyID != g.keyID {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	return newDecryptReader(br, g.aead, header, chunkSize), nil
+}
+
+// NewEncryptWriter encrypts a stream with the active key.
+func (k *Keyring) NewEncryptWriter(w io.Writer) (io.WriteCloser, error) {
+	g, err := k.activeKey()
+	if err != nil {
+		return nil, err
+	}
+	return g.NewEncryptWriter(w)
+}
+
+// NewDecryptReader decrypts a stream with the key named in its header.
+func (k *Keyring) NewDecryptReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	keyID, header, chunkSize, err := readStreamHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	g, err := k.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptReader(br, g.aead, header, chunkSize), nil
+}
+
+func readStreamHeader(r *bufio.Reader) (keyID string, header []byte, chunkSize int, err error) {
+	head, err := r.Peek(2)
+	if err != nil || head[0] != VersionStream {
+		return "", nil, 0, ErrEnvelope
+	}
+
+	header = make([]byte, 2+int(head[1])+4+streamPrefixSize)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return "", nil, 0, ErrEnvelope
+	}
+	keyID = string(header[2 : 2+int(head[1])])
+	chunkSize = int(binary.BigEndian.Uint32(header[2+len(keyID):]))
+	if chunkSize == 0 || chunkSize > maxChunkSize {
+		return "", nil, 0, ErrEnvelope
+	}
+	return keyID, header, chunkSize, nil
+}
+
+// streamState derives the nonce and the associated data of each chunk.
+type streamState struct {
+	nonce   [gcmNonceSize]byte
+	aad     []byte // header | final flag
+	counter uint32
+	size    int
+}
+
+func newStreamState(header []byte, chunkSize int) *streamState {
+	s := &streamState{size: chunkSize}
+	copy(s.nonce[:], header[len(header)-streamPrefixSize:])
+	s.aad = append(append(make([]byte, 0, len(header)+1), header...), 0)
+	return s
+}
+
+// next returns the nonce and associated data of the next chunk.
+func (s *streamState) next(final bool) ([]byte, []byte, error) {
+	if s.counter == ^uint32(0) {
+		return nil, nil, errors.New("aes: stream too long")
+	}
+	binary.BigEndian.PutUint32(s.nonce[streamPrefixSize:], s.counter)
+	s.counter++
+	s.aad[len(s.aad)-1] = streamIntermediate
+	if final {
+		s.aad[len(s.aad)-1] = streamFinal
+	}
+	return s.nonce[:], s.aad, nil
+}
+
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	stream *streamState
+	buf    []byte
+	out    []byte
+	closed bool
+	err    error
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.closed {
+		return 0, errors.New("aes: write to closed stream")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// a full chunk is written only once more data follows, so that
+		// Close always has a final chunk to write
+		if len(e.buf) == e.stream.size {
+			if e.err = e.flush(false); e.err != nil {
+				return n, e.err
+			}
+		}
+		m := copy(e.buf[len(e.buf):e.stream.size], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) flush(final bool) error {
+	nonce, aad, err := e.stream.next(final)
+	if err != nil {
+		return err
+	}
+	e.out = e.aead.Seal(e.out[:0], nonce, e.buf, aad)
+	e.buf = e.buf[:0]
+	_, err = e.w.Write(e.out)
+	return err
+}
+
+// Close writes the final chunk.
+func (e *encryptWriter) Close() error {
+	if e.closed || e.err != nil {
+		return e.err
+	}
+	e.closed = true
+	e.err = e.flush(true)
+	return e.err
+}
+
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	stream *streamState
+	in     []byte
+	plain  []byte
+	pos    int
+	done   bool
+	err    error
+}
+
+func newDecryptReader(r *bufio.Reader, aead cipher.AEAD, header []byte, chunkSize int) *decryptReader {
+	return &decryptReader{
+		r:      r,
+		aead:   aead,
+		stream: newStreamState(header, chunkSize),
+		in:     make([]byte, chunkSize+streamTagSize),
+	}
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for d.pos == len(d.plain) {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+
+	n := copy(p, d.plain[d.pos:])
+	d.pos += n
+	return n, nil
+}
+
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.in)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		// a short chunk must be the final one
+		if n < streamTagSize {
+			return ErrTruncated
+		}
+		return d.open(d.in[:n], true)
+	case err != nil:
+		return err
+	}
+
+	// a full chunk is final when nothing follows it
+	if _, err = d.r.Peek(1); err == io.EOF {
+		return d.open(d.in, true)
+	} else if err != nil {
+		return err
+	}
+	return d.open(d.in, false)
+}
+
+func (d *decryptReader) open(chunk []byte, final bool) error {
+	nonce, aad, err := d.stream.next(final)
+	if err != nil {
+		return err
+	}
+	plain, err := d.aead.Open(d.plain[:0], nonce, chunk, aad)
+	if err != nil {
+		d.plain, d.pos = d.plain[:0], 0
+		if final {
+			// the chunk may be an intermediate one of a truncated stream
+			if _, intermediateErr := d.aead.Open(nil, nonce, chunk, append(aad[:len(aad)-1:len(aad)-1], streamIntermediate)); intermediateErr == nil {
+				return ErrTruncated
+			}
+		}
+		return ErrAuth
+	}
+	d.plain, d.pos = plain, 0
+	d.done = final
+	return nil
+}
+
+// EncryptFile encrypts src into dst in constant memory. dst is written to a
+// temporary file first and renamed once complete.
+func EncryptFile(c StreamCipher, src, dst string) error {
+	return transformFile(src, dst, func(in io.Reader, out io.Writer) error {
+		w, err := c.NewEncryptWriter(out)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(w, in); err != nil {
+			return err
+		}
+		return w.Close()
+	})
+}
+
+// DecryptFile decrypts src into dst in constant memory. dst only appears
+// once the whole file has been authenticated.
+func DecryptFile(c StreamCipher, src, dst string) error {
+	return transformFile(src, dst, func(in io.Reader, out io.Writer) error {
+		r, err := c.NewDecryptReader(in)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, r)
+		return err
+	})
+}
+
+func transformFile(src, dst string, transform func(io.Reader, io.Writer) error) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	out := bufio.NewWriterSize(tmp, DefaultChunkSize)
+	if err = transform(bufio.NewReaderSize(in, DefaultChunkSize), out); err != nil {
+		return err
+	}
+	if err = out.Flush(); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,143 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptStream(t *testing.T, c StreamCipher, plain []byte) []byte {
+	var buf bytes.Buffer
+	w, err := c.NewEncryptWriter(&buf)
+	assert.NoError(t, err)
+	// odd write sizes across the chunk boundaries
+	for p := plain; len(p) > 0; {
+		n := min(len(p), 10007)
+		_, err = w.Write(p[:n])
+		assert.NoError(t, err)
+		p = p[n:]
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func decryptStream(c StreamCipher, data []byte) ([]byte, error) {
+	r, err := c.NewDecryptReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	g, _ := NewGCM("k1", gcmKey)
+	for _, size := range []int{0, 1, DefaultChunkSize - 1, DefaultChunkSize, DefaultChunkSize + 1, 3 * DefaultChunkSize, 200000} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		enc := encryptStream(t, g, plain)
+		chunks := max((size+DefaultChunkSize-1)/DefaultChunkSize, 1)
+		assert.Len(t, enc, 2+2+4+8+size+chunks*16, "size %d", size)
+
+		dec, err := decryptStream(g, enc)
+		assert.NoError(t, err, "size %d", size)
+		assert.Equal(t, plain, dec, "size %d", size)
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	g, _ := NewGCM("k1", gcmKey)
+	plain := bytes.Repeat([]byte("0123456789"), DefaultChunkSize/10*3)
+	enc := encryptStream(t, g, plain)
+	header := 2 + 2 + 4 + 8
+	chunk := DefaultChunkSize + 16
+
+	// truncated at a chunk boundary and inside a chunk
+	_, err := decryptStream(g, enc[:header+chunk])
+	assert.ErrorIs(t, err, ErrTruncated)
+	_, err = decryptStream(g, enc[:header+chunk+100])
+	assert.ErrorIs(t, err, ErrAuth)
+	_, err = decryptStream(g, enc[:header+5])
+	assert.ErrorIs(t, err, ErrTruncated)
+
+	// reordered chunks
+	reordered := bytes.Clone(enc)
+	copy(reordered[header:], enc[header+chunk:header+2*chunk])
+	copy(reordered[header+chunk:], enc[header:header+chunk])
+	_, err = decryptStream(g, reordered)
+	assert.ErrorIs(t, err, ErrAuth)
+
+	// appended data
+	_, err = decryptStream(g, append(bytes.Clone(enc), enc[header:header+chunk]...))
+	assert.ErrorIs(t, err, ErrAuth)
+
+	// flipped bit
+	flipped := bytes.Clone(enc)
+	flipped[len(flipped)-30] ^= 1
+	_, err = decryptStream(g, flipped)
+	assert.ErrorIs(t, err, ErrAuth)
+
+	// changed chunk size in the header
+	resized := bytes.Clone(enc)
+	resized[5]--
+	_, err = decryptStream(g, resized)
+	assert.Error(t, err)
+
+	other, _ := NewGCM("k2", gcmKey)
+	_, err = decryptStream(other, enc)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = decryptStream(g, []byte("not a stream"))
+	assert.ErrorIs(t, err, ErrEnvelope)
+}
+
+func TestStreamKeyring(t *testing.T) {
+	k := NewKeyring()
+	_ = k.Add("v1", key1)
+	_ = k.SetActive("v1")
+	enc := encryptStream(t, k, []byte("backup"))
+	_ = k.Add("v2", key2)
+	_ = k.SetActive("v2")
+
+	dec, err := decryptStream(k, enc)
+	assert.NoError(t, err)
+	assert.Equal(t, "backup", string(dec))
+}
+
+func TestEncryptFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "backup.tar")
+	plain := make([]byte, 300000)
+	_, _ = rand.Read(plain)
+	assert.NoError(t, os.WriteFile(src, plain, 0o600))
+
+	g, _ := NewGCM("k1", gcmKey)
+	assert.NoError(t, EncryptFile(g, src, src+".enc"))
+	assert.NoError(t, DecryptFile(g, src+".enc", src+".dec"))
+	dec, _ := os.ReadFile(src + ".dec")
+	assert.Equal(t, plain, dec)
+
+	// a truncated file leaves no output behind
+	enc, _ := os.ReadFile(src + ".enc")
+	assert.NoError(t, os.WriteFile(src+".enc", enc[:len(enc)-1], 0o600))
+	assert.Error(t, DecryptFile(g, src+".enc", src+".bad"))
+	assert.NoFileExists(t, src+".bad")
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 3)
+}
+
+func BenchmarkStream(b *testing.B) {
+	g, _ := NewGCM("k1", gcmKey)
+	data := make([]byte, 1<<20)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w, _ := g.NewEncryptWriter(io.Discard)
+		_, _ = w.Write(data)
+		_ = w.Close()
+	}
+}
@@ -0,0 +1,234 @@
+// Package cert inspects X.509 certificates: it parses PEM bundles,
+// verifies chains against custom roots, summarizes certificates and scans
+// directories for the ones about to expire.
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoCertificate is returned for data without any certificate.
+var ErrNoCertificate = errors.New("cert: no certificate found")
+
+// ParsePEM parses all the CERTIFICATE blocks of a PEM bundle, in order,
+// skipping other blocks such as private keys. Data without PEM armor is
+// parsed as DER.
+func ParsePEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cert: certificate %d: %w", len(certs), err)
+		}
+		certs = append(certs, c)
+	}
+
+	if len(certs) == 0 {
+		if c, err := x509.ParseCertificates(data); err == nil && len(c) > 0 {
+			return c, nil
+		}
+		return nil, ErrNoCertificate
+	}
+	return certs, nil
+}
+
+// LoadFile parses the certificates of a PEM or DER file.
+func LoadFile(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := ParsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return certs, nil
+}
+
+// LoadPool creates a pool of the certificates of the files, e.g. the
+// internal root CAs.
+func LoadPool(paths ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, path := range paths {
+		certs, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range certs {
+			pool.AddCert(c)
+		}
+	}
+	return pool, nil
+}
+
+// VerifyOption configures Verify.
+type VerifyOption func(*x509.VerifyOptions)
+
+// WithDNSName checks that the leaf is valid for name.
+func WithDNSName(name string) VerifyOption {
+	return func(o *x509.VerifyOptions) { o.DNSName = name }
+}
+
+// WithTime verifies the chain at t instead of now.
+func WithTime(t time.Time) VerifyOption {
+	return func(o *x509.VerifyOptions) { o.CurrentTime = t }
+}
+
+// WithKeyUsages sets the accepted extended key usages, server
+// authentication by default.
+func WithKeyUsages(usages ...x509.ExtKeyUsage) VerifyOption {
+	return func(o *x509.VerifyOptions) { o.KeyUsages = usages }
+}
+
+// WithIntermediates adds intermediates that are not in the bundle.
+func WithIntermediates(certs ...*x509.Certificate) VerifyOption {
+	return func(o *x509.VerifyOptions) {
+		for _, c := range certs {
+			o.Intermediates.AddCert(c)
+		}
+	}
+}
+
+// Verify builds the chains from bundle[0], the leaf, to roots, using the
+// rest of bundle as intermediates as servers send them. A nil roots uses
+// the system roots.
+func Verify(bundle []*x509.Certificate, roots *x509.CertPool, opts ...VerifyOption) ([][]*x509.Certificate, error) {
+	if len(bundle) == 0 {
+		return nil, ErrNoCertificate
+	}
+	o := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, c := range bundle[1:] {
+		o.Intermediates.AddCert(c)
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return bundle[0].Verify(o)
+}
+
+// Info summarizes a certificate.
+type Info struct {
+	Subject            string
+	Issuer             string
+	CommonName         string
+	SerialNumber       string // hex
+	DNSNames           []string
+	IPAddresses        []string
+	EmailAddresses     []string
+	URIs               []string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	DaysLeft           int // negative once expired
+	KeyType            string
+	KeyBits            int
+	SignatureAlgorithm string
+	IsCA               bool
+	SelfSigned         bool
+	SHA1               string // fingerprint, colon separated hex as in browsers
+	SHA256             string
+}
+
+// Inspect summarizes c, counting DaysLeft from now.
+func Inspect(c *x509.Certificate, now time.Time) Info {
+	info := Info{
+		Subject:            c.Subject.String(),
+		Issuer:             c.Issuer.String(),
+		CommonName:         c.Subject.CommonName,
+		SerialNumber:       c.SerialNumber.Text(16),
+		DNSNames:           c.DNSNames,
+		EmailAddresses:     c.EmailAddresses,
+		NotBefore:          c.NotBefore,
+		NotAfter:           c.NotAfter,
+		DaysLeft:           DaysLeft(c, now),
+		SignatureAlgorithm: c.SignatureAlgorithm.String(),
+		IsCA:               c.IsCA,
+		SelfSigned:         selfSigned(c),
+		SHA1:               Fingerprint(c, crypto.SHA1),
+		SHA256:             Fingerprint(c, crypto.SHA256),
+	}
+	info.KeyType, info.KeyBits = KeyInfo(c)
+	for _, ip := range c.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+	for _, u := range c.URIs {
+		info.URIs = append(info.URIs, u.String())
+	}
+	return info
+}
+
+// InspectAll summarizes every certificate of a bundle.
+func InspectAll(certs []*x509.Certificate, now time.Time) []Info {
+	infos := make([]Info, len(certs))
+	for i, c := range certs {
+		infos[i] = Inspect(c, now)
+	}
+	return infos
+}
+
+// selfSigned also holds for leaves, which CheckSignatureFrom rejects as
+// they are not CAs.
+func selfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawIssuer, c.RawSubject) &&
+		c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil
+}
+
+// DaysLeft returns the number of whole days until c expires, negative once
+// it has expired.
+func DaysLeft(c *x509.Certificate, now time.Time) int {
+	return int(math.Floor(c.NotAfter.Sub(now).Hours() / 24))
+}
+
+// KeyInfo returns the type and size in bits of the public key of c.
+func KeyInfo(c *x509.Certificate) (string, int) {
+	switch k := c.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return c.PublicKeyAlgorithm.String(), 0
+	}
+}
+
+// Fingerprint returns the fingerprint of c with hash, usually
+// crypto.SHA256, as colon separated uppercase hex.
+func Fingerprint(c *x509.Certificate, hash crypto.Hash) string {
+	h := hash.New()
+	h.Write(c.Raw)
+	sum := strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+
+	var b strings.Builder
+	for i := 0; i < len(sum); i += 2 {
+		if i > 0 {
+			b.WriteByte(':')
+		}
+		b.WriteString(sum[i : i+2])
+	}
+	return b.String()
+}
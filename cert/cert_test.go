@@ -0,0 +1,220 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func issue(t *testing.T, tmpl *x509.Certificate, parent *testCert, key crypto.Signer) *testCert {
+	t.Helper()
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	if tmpl.NotBefore.IsZero() {
+		tmpl.NotBefore = now.Add(-time.Hour)
+	}
+	parentCert, parentKey := tmpl, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, key.Public(), parentKey)
+	must(t, err)
+	c, err := x509.ParseCertificate(der)
+	must(t, err)
+	return &testCert{cert: c, key: key}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ecKey(t *testing.T) crypto.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	must(t, err)
+	return key
+}
+
+// chain returns a root, an intermediate and a leaf for example.com
+// expiring in leafDays.
+func chain(t *testing.T, leafDays int) (root, inter, leaf *testCert) {
+	ca := &x509.Certificate{
+		NotAfter:              now.AddDate(10, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca.Subject = pkix.Name{CommonName: "Test Root"}
+	root = issue(t, ca, nil, ecKey(t))
+
+	ica := *ca
+	ica.Subject = pkix.Name{CommonName: "Test Intermediate"}
+	inter = issue(t, &ica, root, ecKey(t))
+
+	leaf = issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "example.com"},
+		DNSNames:    []string{"example.com", "www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+		NotAfter:    now.Add(time.Duration(leafDays)*24*time.Hour + time.Hour),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	}, inter, ecKey(t))
+	return root, inter, leaf
+}
+
+func encode(certs ...*testCert) []byte {
+	var out []byte
+	for _, c := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})...)
+	}
+	return out
+}
+
+func TestParsePEM(t *testing.T) {
+	root, inter, leaf := chain(t, 90)
+
+	bundle := append(encode(leaf), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("skipped")})...)
+	bundle = append(bundle, encode(inter, root)...)
+	certs, err := ParsePEM(bundle)
+	must(t, err)
+	if !assert.Len(t, certs, 3) {
+		return
+	}
+	assert.Equal(t, "example.com", certs[0].Subject.CommonName)
+	assert.Equal(t, "Test Root", certs[2].Subject.CommonName)
+
+	certs, err = ParsePEM(leaf.cert.Raw)
+	assert.NoError(t, err)
+	assert.Len(t, certs, 1)
+
+	_, err = ParsePEM([]byte("nothing here"))
+	assert.ErrorIs(t, err, ErrNoCertificate)
+	_, err = ParsePEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("bad")}))
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	root, inter, leaf := chain(t, 90)
+	dir := t.TempDir()
+	rootFile := filepath.Join(dir, "root.pem")
+	must(t, os.WriteFile(rootFile, encode(root), 0o600))
+	roots, err := LoadPool(rootFile)
+	must(t, err)
+
+	bundle := []*x509.Certificate{leaf.cert, inter.cert}
+	chains, err := Verify(bundle, roots, WithTime(now), WithDNSName("www.example.com"))
+	must(t, err)
+	if !assert.Len(t, chains, 1) {
+		return
+	}
+	assert.Len(t, chains[0], 3)
+
+	_, err = Verify(bundle, roots, WithTime(now), WithDNSName("other.com"))
+	assert.Error(t, err)
+	_, err = Verify(bundle, roots, WithTime(now.AddDate(1, 0, 0)))
+	assert.Error(t, err)
+	_, err = Verify(bundle[:1], roots, WithTime(now))
+	assert.Error(t, err, "missing intermediate")
+	_, err = Verify(bundle[:1], roots, WithTime(now), WithIntermediates(inter.cert))
+	assert.NoError(t, err)
+	_, err = Verify(bundle, x509.NewCertPool(), WithTime(now))
+	assert.Error(t, err, "unknown root")
+	_, err = Verify(nil, roots)
+	assert.ErrorIs(t, err, ErrNoCertificate)
+}
+
+func TestInspect(t *testing.T) {
+	root, _, leaf := chain(t, 45)
+
+	info := Inspect(leaf.cert, now)
+	assert.Equal(t, "example.com", info.CommonName)
+	assert.Equal(t, "CN=Test Intermediate", info.Issuer)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, info.DNSNames)
+	assert.Equal(t, []string{"10.0.0.1"}, info.IPAddresses)
+	assert.Equal(t, 45, info.DaysLeft)
+	assert.Equal(t, "ECDSA", info.KeyType)
+	assert.Equal(t, 256, info.KeyBits)
+	assert.Equal(t, "ECDSA-SHA256", info.SignatureAlgorithm)
+	assert.False(t, info.IsCA)
+	assert.False(t, info.SelfSigned)
+	assert.Len(t, info.SHA256, 32*3-1)
+	assert.Len(t, info.SHA1, 20*3-1)
+	assert.Equal(t, -46, Inspect(leaf.cert, now.AddDate(0, 0, 91)).DaysLeft)
+
+	info = Inspect(root.cert, now)
+	assert.True(t, info.IsCA)
+	assert.True(t, info.SelfSigned)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	self := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "self"}, NotAfter: now.AddDate(1, 0, 0)}, nil, rsaKey)
+	info = Inspect(self.cert, now)
+	assert.Equal(t, "RSA", info.KeyType)
+	assert.Equal(t, 2048, info.KeyBits)
+	assert.True(t, info.SelfSigned)
+}
+
+func TestExpiringWithin(t *testing.T) {
+	dir := t.TempDir()
+	must(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o700))
+
+	_, _, soon := chain(t, 10)
+	_, inter, later := chain(t, 200)
+	_, _, expired := chain(t, -3)
+	files := map[string][]byte{
+		"soon.crt":        encode(soon),
+		"later.pem":       encode(later, inter),
+		"sub/expired.PEM": encode(expired),
+		"broken.pem":      []byte("not a certificate"),
+		"notes.txt":       []byte("ignored"),
+		"sub/key.key":     []byte("ignored"),
+		"sub/key.pem":     pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("skipped")}),
+	}
+	for name, data := range files {
+		must(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	scanned, err := ScanDir(dir, now)
+	must(t, err)
+	assert.Len(t, scanned, 4)
+
+	found, err := ExpiringWithin(dir, 30, now)
+	assert.ErrorIs(t, err, ErrNoCertificate)
+	assert.ErrorContains(t, err, "broken.pem")
+	if !assert.Len(t, found, 2) {
+		return
+	}
+	assert.Equal(t, filepath.Join(dir, "sub/expired.PEM"), found[0].Path)
+	assert.Equal(t, -3, found[0].DaysLeft)
+	assert.Equal(t, filepath.Join(dir, "soon.crt"), found[1].Path)
+	assert.Equal(t, 10, found[1].DaysLeft)
+
+	// the intermediate of later.pem is the second certificate of its bundle
+	found, _ = ExpiringWithin(dir, 365*20, now)
+	assert.Len(t, found, 4)
+	assert.Equal(t, 1, found[3].Index)
+
+	_, err = ScanDir(filepath.Join(dir, "missing"), now)
+	assert.Error(t, err)
+}
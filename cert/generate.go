@@ -0,0 +1,304 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCAValidity is the validity of the CAs of NewCA.
+	DefaultCAValidity = 10 * 365 * 24 * time.Hour
+
+	// DefaultValidity is the validity of the certificates a CA issues.
+	DefaultValidity = 365 * 24 * time.Hour
+
+	// backdate tolerates clocks slightly behind the issuer's
+	backdate = 5 * time.Minute
+)
+
+// KeyType is the type of the generated keys.
+type KeyType int
+
+const (
+	// ECDSA is P-256, or P-384 with WithKeyBits(384).
+	ECDSA KeyType = iota
+	// RSA is 2048 bits by default.
+	RSA
+)
+
+type options struct {
+	keyType   KeyType
+	keyBits   int
+	validity  time.Duration
+	notBefore time.Time
+	subject   pkix.Name
+}
+
+// Option configures generated certificates.
+type Option func(*options)
+
+// WithKeyType sets the key type, ECDSA by default.
+func WithKeyType(t KeyType) Option {
+	return func(o *options) { o.keyType = t }
+}
+
+// WithKeyBits sets the key size: 256 or 384 for ECDSA, at least 2048 for
+// RSA.
+func WithKeyBits(bits int) Option {
+	return func(o *options) { o.keyBits = bits }
+}
+
+// WithValidity sets how long the certificate is valid.
+func WithValidity(d time.Duration) Option {
+	return func(o *options) { o.validity = d }
+}
+
+// WithNotBefore sets the start of the validity, a few minutes ago by
+// default. A date in the past makes expired certificates, to test
+// monitoring.
+func WithNotBefore(t time.Time) Option {
+	return func(o *options) { o.notBefore = t }
+}
+
+// WithSubject sets the subject, e.g. to add an organization. Its
+// CommonName is overridden by the one given to NewCA or IssueClient.
+func WithSubject(name pkix.Name) Option {
+	return func(o *options) { o.subject = name }
+}
+
+func newOptions(validity time.Duration, opts []Option) options {
+	o := options{validity: validity}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o *options) generateKey() (crypto.Signer, error) {
+	switch o.keyType {
+	case ECDSA:
+		switch o.keyBits {
+		case 0, 256:
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		case 384:
+			return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		}
+		return nil, fmt.Errorf("cert: unsupported ECDSA key size %d", o.keyBits)
+	case RSA:
+		bits := o.keyBits
+		if bits == 0 {
+			bits = 2048
+		}
+		if bits < 2048 {
+			return nil, fmt.Errorf("cert: RSA key size %d is less than 2048 bits", bits)
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	}
+	return nil, errors.New("cert: unknown key type")
+}
+
+func (o *options) template(commonName string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	notBefore, notAfter := o.notBefore, o.notBefore.Add(o.validity)
+	if o.notBefore.IsZero() {
+		now := time.Now()
+		notBefore, notAfter = now.Add(-backdate), now.Add(o.validity)
+	}
+	subject := o.subject
+	subject.CommonName = commonName
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+// Pair is a certificate and its private key.
+type Pair struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// LoadPair loads a PEM certificate and private key, as written by
+// WriteFiles.
+func LoadPair(certFile, keyFile string) (*Pair, error) {
+	tc, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := tc.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("cert: %T is not a signing key", tc.PrivateKey)
+	}
+	return &Pair{Cert: tc.Leaf, Key: key}, nil
+}
+
+// CertPEM encodes the certificate in PEM.
+func (p *Pair) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.Cert.Raw})
+}
+
+// KeyPEM encodes the private key in PKCS#8 PEM.
+func (p *Pair) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(p.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles writes the certificate and the key in PEM, the key readable
+// by the owner only.
+func (p *Pair) WriteFiles(certFile, keyFile string) error {
+	keyPEM, err := p.KeyPEM()
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(certFile, p.CertPEM(), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(keyFile, keyPEM, 0o600)
+}
+
+// TLSCertificate returns the pair for tls.Config.Certificates.
+func (p *Pair) TLSCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{p.Cert.Raw}, PrivateKey: p.Key, Leaf: p.Cert}
+}
+
+// CA is a certificate authority issuing server and client certificates.
+type CA struct {
+	Pair
+}
+
+// NewCA generates a self-signed CA valid for DefaultCAValidity. It can only
+// issue leaf certificates.
+func NewCA(commonName string, opts ...Option) (*CA, error) {
+	o := newOptions(DefaultCAValidity, opts)
+	key, err := o.generateKey()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := o.template(commonName)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.IsCA = true
+	tmpl.MaxPathLenZero = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	c, err := create(tmpl, tmpl, key, key)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Pair{Cert: c, Key: key}}, nil
+}
+
+// LoadCA loads a CA written by WriteFiles, to keep issuing certificates
+// trusted by existing clients.
+func LoadCA(certFile, keyFile string) (*CA, error) {
+	p, err := LoadPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Cert.IsCA {
+		return nil, fmt.Errorf("cert: %s is not a CA", certFile)
+	}
+	return &CA{*p}, nil
+}
+
+// Pool returns a pool trusting the CA.
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+// IssueServer issues a server certificate for hosts, which are DNS names,
+// IP addresses, URIs such as SPIFFE IDs, or email addresses. The first host
+// is the common name.
+func (ca *CA) IssueServer(hosts []string, opts ...Option) (*Pair, error) {
+	if len(hosts) == 0 {
+		return nil, errors.New("cert: a server certificate needs at least one host")
+	}
+	return ca.issue(hosts[0], hosts, x509.ExtKeyUsageServerAuth, opts)
+}
+
+// IssueClient issues a client certificate for commonName, the identity of
+// the client, with optional SANs as in IssueServer.
+func (ca *CA) IssueClient(commonName string, hosts []string, opts ...Option) (*Pair, error) {
+	return ca.issue(commonName, hosts, x509.ExtKeyUsageClientAuth, opts)
+}
+
+func (ca *CA) issue(commonName string, hosts []string, usage x509.ExtKeyUsage, opts []Option) (*Pair, error) {
+	o := newOptions(DefaultValidity, opts)
+	key, err := o.generateKey()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := o.template(commonName)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if err = addSANs(tmpl, hosts); err != nil {
+		return nil, err
+	}
+
+	c, err := create(tmpl, ca.Cert, key, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &Pair{Cert: c, Key: key}, nil
+}
+
+func addSANs(tmpl *x509.Certificate, hosts []string) error {
+	for _, h := range hosts {
+		switch {
+		case net.ParseIP(h) != nil:
+			tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(h))
+		case strings.Contains(h, "://"):
+			u, err := url.Parse(h)
+			if err != nil {
+				return fmt.Errorf("cert: invalid URI SAN %q: %w", h, err)
+			}
+			tmpl.URIs = append(tmpl.URIs, u)
+		case strings.Contains(h, "@"):
+			tmpl.EmailAddresses = append(tmpl.EmailAddresses, h)
+		default:
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	return nil
+}
+
+func create(tmpl, parent *x509.Certificate, key, parentKey crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("cert: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
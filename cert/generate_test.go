@@ -0,0 +1,152 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/v-mars/library/utils"
+)
+
+func TestIssue(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithKeyBits(384)},
+		{WithKeyType(RSA)},
+	} {
+		ca, err := NewCA("Internal CA", append(opts, WithSubject(pkix.Name{Organization: []string{"ACME"}}))...)
+		must(t, err)
+		assert.True(t, ca.Cert.IsCA)
+		assert.Equal(t, []string{"ACME"}, ca.Cert.Subject.Organization)
+		assert.Equal(t, "Internal CA", ca.Cert.Subject.CommonName)
+
+		server, err := ca.IssueServer([]string{"api.internal", "10.1.2.3", "spiffe://acme/api", "ops@acme.io"},
+			append(opts, WithValidity(30*24*time.Hour))...)
+		must(t, err)
+		info := Inspect(server.Cert, time.Now())
+		assert.Equal(t, "api.internal", info.CommonName)
+		assert.Equal(t, []string{"api.internal"}, info.DNSNames)
+		assert.Equal(t, []string{"10.1.2.3"}, info.IPAddresses)
+		assert.Equal(t, []string{"spiffe://acme/api"}, info.URIs)
+		assert.Equal(t, []string{"ops@acme.io"}, info.EmailAddresses)
+		assert.Equal(t, 29, info.DaysLeft)
+
+		_, err = Verify([]*x509.Certificate{server.Cert}, ca.Pool(), WithDNSName("api.internal"))
+		assert.NoError(t, err)
+
+		client, err := ca.IssueClient("svc-billing", nil, opts...)
+		must(t, err)
+		_, err = Verify([]*x509.Certificate{client.Cert}, ca.Pool(), WithKeyUsages(x509.ExtKeyUsageClientAuth))
+		assert.NoError(t, err)
+		_, err = Verify([]*x509.Certificate{client.Cert}, ca.Pool())
+		assert.Error(t, err, "not a server certificate")
+	}
+
+	ca, _ := NewCA("CA", WithKeyType(RSA), WithKeyBits(4096))
+	if assert.NotNil(t, ca) {
+		_, bits := KeyInfo(ca.Cert)
+		assert.Equal(t, 4096, bits)
+	}
+	for _, opt := range []Option{WithKeyBits(521), WithKeyType(9)} {
+		_, err := NewCA("CA", opt)
+		assert.Error(t, err)
+	}
+	_, err := NewCA("CA", WithKeyType(RSA), WithKeyBits(1024))
+	assert.Error(t, err)
+	_, err = ca.IssueServer(nil)
+	assert.Error(t, err)
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := NewCA("CA")
+	must(t, err)
+	caCert, caKey := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	must(t, ca.WriteFiles(caCert, caKey))
+
+	st, err := os.Stat(caKey)
+	must(t, err)
+	assert.Equal(t, os.FileMode(0o600), st.Mode().Perm())
+
+	loaded, err := LoadCA(caCert, caKey)
+	must(t, err)
+	assert.Equal(t, ca.Cert.Raw, loaded.Cert.Raw)
+
+	// the loaded CA issues certificates the original pool trusts
+	server, err := loaded.IssueServer([]string{"localhost"})
+	must(t, err)
+	_, err = Verify([]*x509.Certificate{server.Cert}, ca.Pool())
+	assert.NoError(t, err)
+
+	must(t, server.WriteFiles(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")))
+	_, err = LoadCA(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"))
+	assert.Error(t, err)
+	pair, err := LoadPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem"))
+	must(t, err)
+	assert.Equal(t, server.Cert.Raw, pair.Cert.Raw)
+
+	// expired certificates for the expiry scan
+	now := time.Now().Truncate(time.Second) // certificate times have no fractions
+	old, err := ca.IssueServer([]string{"old.internal"}, WithNotBefore(now.AddDate(0, 0, -40)), WithValidity(30*24*time.Hour))
+	must(t, err)
+	must(t, old.WriteFiles(filepath.Join(dir, "old.pem"), filepath.Join(dir, "old-key.key")))
+	found, err := ExpiringWithin(dir, 30, now)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "old.internal", found[0].CommonName)
+		assert.Equal(t, -10, found[0].DaysLeft)
+	}
+}
+
+func TestMTLS(t *testing.T) {
+	m, err := NewMTLS(nil)
+	must(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"client":"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // the rejected handshakes
+	srv.TLS = m.ServerConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	var out struct{ Client string }
+	_, resp, err := utils.Request(srv.URL, http.MethodGet, nil, m.HTTPClient(), utils.ReqParam{}, &out)
+	assert.NoError(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, "client", out.Client)
+
+	// a client without certificate is rejected
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: ClientConfig(m.CA, nil)}}
+	_, err = anonymous.Get(srv.URL)
+	assert.Error(t, err)
+
+	// and so is a client that does not trust the CA
+	other, _ := NewMTLS(nil)
+	_, err = other.HTTPClient().Get(srv.URL)
+	assert.Error(t, err)
+
+	// the validity options apply to the leaves only
+	short, err := NewMTLS([]string{"svc.internal"}, WithValidity(24*time.Hour), WithKeyType(RSA))
+	must(t, err)
+	assert.Equal(t, 0, Inspect(short.Server.Cert, time.Now()).DaysLeft)
+	assert.Equal(t, 0, Inspect(short.Client.Cert, time.Now()).DaysLeft)
+	assert.Greater(t, Inspect(short.CA.Cert, time.Now()).DaysLeft, 3000)
+	keyType, _ := KeyInfo(short.CA.Cert)
+	assert.Equal(t, "RSA", keyType)
+
+	conf := ServerConfig(m.Server, nil)
+	assert.Equal(t, tls.NoClientCert, conf.ClientAuth)
+}
@@ -0,0 +1,93 @@
+package cert
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Extensions are the file extensions ScanDir reads.
+var Extensions = []string{".pem", ".crt", ".cer", ".cert"}
+
+// File is a certificate file found by ScanDir.
+type File struct {
+	Path  string
+	Certs []Info
+	Err   error // the file could not be read or parsed
+}
+
+// ScanDir inspects the certificate files under dir, recursively. PEM files
+// without certificates, such as keys, are skipped. Files that fail to
+// parse are returned with Err set; the error is only for dir itself.
+func ScanDir(dir string, now time.Time) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			files = append(files, File{Path: path, Err: err})
+			return nil
+		}
+		if d.IsDir() || !slices.Contains(Extensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+
+		f := File{Path: path}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Err = err
+		} else if certs, err := ParsePEM(data); err == nil {
+			f.Certs = InspectAll(certs, now)
+		} else if block, _ := pem.Decode(data); block != nil && errors.Is(err, ErrNoCertificate) {
+			// a private key or a CSR, which are often named .pem too
+			return nil
+		} else {
+			f.Err = fmt.Errorf("%s: %w", path, err)
+		}
+		files = append(files, f)
+		return nil
+	})
+	return files, err
+}
+
+// Expiring is a certificate expiring soon.
+type Expiring struct {
+	Path  string
+	Index int // in the bundle
+	Info
+}
+
+// ExpiringWithin returns the certificates under dir that expire within
+// days, or have expired, soonest first. Files that could not be parsed are
+// reported in the error, after all the others have been checked.
+func ExpiringWithin(dir string, days int, now time.Time) ([]Expiring, error) {
+	files, err := ScanDir(dir, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Expiring
+	var errs []error
+	for _, f := range files {
+		if f.Err != nil {
+			errs = append(errs, f.Err)
+			continue
+		}
+		for i, info := range f.Certs {
+			if info.DaysLeft < days {
+				found = append(found, Expiring{Path: f.Path, Index: i, Info: info})
+			}
+		}
+	}
+	slices.SortStableFunc(found, func(a, b Expiring) int {
+		return a.NotAfter.Compare(b.NotAfter)
+	})
+	return found, errors.Join(errs...)
+}
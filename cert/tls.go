@@ -0,0 +1,92 @@
+package cert
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// LocalHosts are the hosts of the server certificate of NewMTLS by
+// default, the addresses of httptest servers.
+var LocalHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// ServerConfig returns a TLS 1.2+ server configuration presenting server.
+// With a CA, clients must present a certificate it issued.
+func ServerConfig(server *Pair, clientCA *CA) *tls.Config {
+	conf := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{server.TLSCertificate()},
+	}
+	if clientCA != nil {
+		conf.ClientCAs = clientCA.Pool()
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return conf
+}
+
+// ClientConfig returns a TLS 1.2+ client configuration trusting serverCA
+// and, when client is not nil, presenting it.
+func ClientConfig(serverCA *CA, client *Pair) *tls.Config {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    serverCA.Pool(),
+	}
+	if client != nil {
+		conf.Certificates = []tls.Certificate{client.TLSCertificate()}
+	}
+	return conf
+}
+
+// MTLS is a throwaway CA with a server and a client certificate, for tests
+// and internal services:
+//
+//	m, _ := cert.NewMTLS(nil)
+//	srv := httptest.NewUnstartedServer(handler)
+//	srv.TLS = m.ServerConfig()
+//	srv.StartTLS()
+//	utils.Request(srv.URL, http.MethodGet, nil, m.HTTPClient(), utils.ReqParam{}, &out)
+type MTLS struct {
+	CA     *CA
+	Server *Pair
+	Client *Pair
+}
+
+// NewMTLS generates a CA, a server certificate for hosts, LocalHosts by
+// default, and a client certificate named "client". The options apply to
+// the server and client certificates; the CA only takes their key type and
+// size, and is valid for DefaultCAValidity.
+func NewMTLS(hosts []string, opts ...Option) (*MTLS, error) {
+	if len(hosts) == 0 {
+		hosts = LocalHosts
+	}
+	o := newOptions(0, opts)
+	ca, err := NewCA("Test CA", WithKeyType(o.keyType), WithKeyBits(o.keyBits))
+	if err != nil {
+		return nil, err
+	}
+	server, err := ca.IssueServer(hosts, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ca.IssueClient("client", nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MTLS{CA: ca, Server: server, Client: client}, nil
+}
+
+// ServerConfig requires client certificates of the CA.
+func (m *MTLS) ServerConfig() *tls.Config {
+	return ServerConfig(m.Server, m.CA)
+}
+
+// ClientConfig trusts the CA and presents the client certificate.
+func (m *MTLS) ClientConfig() *tls.Config {
+	return ClientConfig(m.CA, m.Client)
+}
+
+// HTTPClient returns a client using ClientConfig.
+func (m *MTLS) HTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = m.ClientConfig()
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,52 @@
+package ddm
+
+var (
+	idCardWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	idCardCodes   = "10X98765432"
+)
+
+// idCardChecksumOK reports whether the last character of an 18-character
+// ID-card number is the GB 11643 check code of the first 17 digits.
+func idCardChecksumOK(s string) bool {
+	if len(s) != 18 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * idCardWeights[i]
+	}
+	last := s[17]
+	if last == 'x' {
+		last = 'X'
+	}
+	return idCardCodes[sum%11] == last
+}
+
+// luhnOK reports whether the digits of s pass the Luhn check.
+func luhnOK(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
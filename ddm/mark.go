@@ -1,62 +1,50 @@
 package ddm
 
 import (
-	"fmt"
-	"strings"
+	"encoding/json"
 )
 
 func (m Mobile) MarshalJSON() ([]byte, error) {
-	if len(m) != 11 {
-		return []byte(`"` + m + `"`), nil
-	}
-
-	v := fmt.Sprintf("%s****%s", m[:3], m[len(m)-4:])
-	return []byte(`"` + v + `"`), nil
+	return quote(MaskMobile(string(m))), nil
 }
 
 func (bc BankCard) MarshalJSON() ([]byte, error) {
-	if len(bc) > 19 || len(bc) < 16 {
-		return []byte(`"` + bc + `"`), nil
-	}
-
-	v := fmt.Sprintf("%s******%s", bc[:6], bc[len(bc)-4:])
-	return []byte(`"` + v + `"`), nil
+	return quote(MaskBankCard(string(bc))), nil
 }
 
 func (card IDCard) MarshalJSON() ([]byte, error) {
-	if len(card) != 18 {
-		return []byte(`"` + card + `"`), nil
-	}
-
-	v := fmt.Sprintf("%s******%s", card[:1], card[len(card)-1:])
-	return []byte(`"` + v + `"`), nil
+	return quote(MaskIDCard(string(card))), nil
 }
 
 func (name IDName) MarshalJSON() ([]byte, error) {
-	if len(name) < 1 {
-		return []byte(`""`), nil
-	}
-
-	nameRune := []rune(name)
-	v := fmt.Sprintf("*%s", string(nameRune[1:]))
-	return []byte(`"` + v + `"`), nil
+	return quote(MaskName(string(name))), nil
 }
 
 func (pw PassWord) MarshalJSON() ([]byte, error) {
-	v := "******"
-	return []byte(`"` + v + `"`), nil
+	return quote(MaskPassword(string(pw))), nil
 }
 
 func (e Email) MarshalJSON() ([]byte, error) {
-	if !strings.Contains(string(e), "@") {
-		return []byte(`"` + e + `"`), nil
-	}
+	return quote(MaskEmail(string(e))), nil
+}
+
+func (m StrictMobile) MarshalJSON() ([]byte, error) {
+	return Mobile(m).MarshalJSON()
+}
+
+func (bc StrictBankCard) MarshalJSON() ([]byte, error) {
+	return BankCard(bc).MarshalJSON()
+}
 
-	split := strings.Split(string(e), "@")
-	if len(split[0]) < 1 || len(split[1]) < 1 {
-		return []byte(`"` + e + `"`), nil
-	}
+func (card StrictIDCard) MarshalJSON() ([]byte, error) {
+	return IDCard(card).MarshalJSON()
+}
+
+func (e StrictEmail) MarshalJSON() ([]byte, error) {
+	return Email(e).MarshalJSON()
+}
 
-	v := fmt.Sprintf("%s***%s", split[0][:1], split[0][len(split[0])-1:])
-	return []byte(`"` + v + "@" + split[1] + `"`), nil
+func quote(s string) []byte {
+	b, _ := json.Marshal(s)
+	return b
 }
@@ -0,0 +1,189 @@
+package ddm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// TagName is the struct tag read by Mask, e.g. `ddm:"mobile"`,
+// `ddm:"keep=3,4"` or `ddm:"regex=\d{4}$"`. `ddm:"-"` leaves a field as is.
+const TagName = "ddm"
+
+type fieldRule struct {
+	index int
+	rule  string
+}
+
+type ptrKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+var (
+	fieldCache sync.Map // reflect.Type -> []fieldRule
+	walkCache  sync.Map // reflect.Type -> bool
+)
+
+// Mask returns a copy of v in which every string field tagged with a ddm
+// rule is masked. Structs, pointers, maps, slices, arrays and interfaces are
+// walked recursively; the rule of a field also applies to the strings of its
+// slices and map values. Values that cannot contain strings are shared with
+// v rather than copied. A tag naming an unknown rule masks the whole value.
+func Mask[T any](v T) T {
+	rv := reflect.ValueOf(&v).Elem()
+	// a nil interface has no dynamic type to assert to
+	if masked, ok := maskValue(rv, "", map[ptrKey]reflect.Value{}).Interface().(T); ok {
+		return masked
+	}
+	return v
+}
+
+// MaskJSON returns the JSON encoding of the masked copy of v.
+func MaskJSON(v any) ([]byte, error) {
+	return json.Marshal(Mask(v))
+}
+
+func maskValue(v reflect.Value, rule string, seen map[ptrKey]reflect.Value) reflect.Value {
+	t := v.Type()
+	if rule == "" && !needsWalk(t) {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if rule == "" {
+			return v
+		}
+		nv := reflect.New(t).Elem()
+		nv.SetString(applyTag(rule, v.String()))
+		return nv
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		key := ptrKey{ptr: v.Pointer(), typ: t}
+		if nv, ok := seen[key]; ok {
+			return nv
+		}
+		nv := reflect.New(t.Elem())
+		seen[key] = nv
+		nv.Elem().Set(maskValue(v.Elem(), rule, seen))
+		return nv
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.New(t).Elem()
+		nv.Set(maskValue(v.Elem(), rule, seen))
+		return nv
+
+	case reflect.Struct:
+		nv := reflect.New(t).Elem()
+		nv.Set(v)
+		for _, f := range structFields(t) {
+			nv.Field(f.index).Set(maskValue(v.Field(f.index), f.rule, seen))
+		}
+		return nv
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			nv.Index(i).Set(maskValue(v.Index(i), rule, seen))
+		}
+		return nv
+
+	case reflect.Array:
+		nv := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			nv.Index(i).Set(maskValue(v.Index(i), rule, seen))
+		}
+		return nv
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		nv := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			nv.SetMapIndex(iter.Key(), maskValue(iter.Value(), rule, seen))
+		}
+		return nv
+	}
+
+	return v
+}
+
+func applyTag(tag, value string) string {
+	masked, err := ApplyRule(tag, value)
+	if err != nil {
+		return strings.Repeat("*", utf8.RuneCountInString(value))
+	}
+	return masked
+}
+
+// structFields returns the exported fields of t which are tagged or may
+// contain tagged fields.
+func structFields(t reflect.Type) []fieldRule {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]fieldRule)
+	}
+
+	var fields []fieldRule
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+		if tag != "" || needsWalk(sf.Type) {
+			fields = append(fields, fieldRule{index: i, rule: tag})
+		}
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// needsWalk reports whether a value of t may hold a tagged field.
+func needsWalk(t reflect.Type) bool {
+	if w, ok := walkCache.Load(t); ok {
+		return w.(bool)
+	}
+	// recursive types are walked while their result is being computed
+	walkCache.Store(t, true)
+
+	var w bool
+	switch t.Kind() {
+	case reflect.Interface:
+		w = true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		w = needsWalk(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !w; i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if tag := sf.Tag.Get(TagName); tag != "" && tag != "-" {
+				w = true
+			} else if tag == "" {
+				w = needsWalk(sf.Type)
+			}
+		}
+	}
+
+	walkCache.Store(t, w)
+	return w
+}
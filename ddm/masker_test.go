@@ -0,0 +1,113 @@
+package ddm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	City   string
+	Street string `ddm:"keep=2,0"`
+}
+
+type user struct {
+	Name     string            `json:"name" ddm:"name"`
+	Mobile   string            `json:"mobile" ddm:"mobile"`
+	IDCard   string            `json:"id_card" ddm:"id_card"`
+	Card     string            `json:"card" ddm:"keep=6,4"`
+	Token    string            `json:"token" ddm:"regex=^sk-(\\w+)$"`
+	Secret   string            `json:"secret" ddm:"no_such_rule"`
+	Raw      string            `json:"raw" ddm:"-"`
+	Emails   []string          `json:"emails" ddm:"email"`
+	Extra    map[string]string `json:"extra" ddm:"full"`
+	Age      int               `json:"age"`
+	Home     *address          `json:"home"`
+	Any      any               `json:"any"`
+	Friends  []*user           `json:"friends"`
+	internal string
+}
+
+func TestMask(t *testing.T) {
+	home := &address{City: "Shanghai", Street: "Nanjing Road"}
+	u := &user{
+		Name:     "李鸿章",
+		Mobile:   "13288887986",
+		IDCard:   "125252525252525252",
+		Card:     "6545654565456545",
+		Token:    "sk-abc123",
+		Secret:   "top",
+		Raw:      "raw",
+		Emails:   []string{"xinliangnote@163.com"},
+		Extra:    map[string]string{"k": "value"},
+		Age:      30,
+		Home:     home,
+		Any:      address{Street: "abcdef"},
+		Friends:  []*user{{Mobile: "13000000000"}},
+		internal: "kept",
+	}
+
+	m := Mask(u)
+	assert.Equal(t, "*鸿章", m.Name)
+	assert.Equal(t, "132****7986", m.Mobile)
+	assert.Equal(t, "1******2", m.IDCard)
+	assert.Equal(t, "654565******6545", m.Card)
+	assert.Equal(t, "sk-******", m.Token)
+	assert.Equal(t, "***", m.Secret)
+	assert.Equal(t, "raw", m.Raw)
+	assert.Equal(t, []string{"x***e@163.com"}, m.Emails)
+	assert.Equal(t, map[string]string{"k": "*****"}, m.Extra)
+	assert.Equal(t, 30, m.Age)
+	assert.Equal(t, "Shanghai", m.Home.City)
+	assert.Equal(t, "Na**********", m.Home.Street)
+	assert.Equal(t, address{Street: "ab****"}, m.Any)
+	assert.Equal(t, "130****0000", m.Friends[0].Mobile)
+	assert.Equal(t, "kept", m.internal)
+
+	// the original is untouched
+	assert.Equal(t, "13288887986", u.Mobile)
+	assert.Equal(t, "Nanjing Road", home.Street)
+	assert.Equal(t, "xinliangnote@163.com", u.Emails[0])
+	assert.Equal(t, "value", u.Extra["k"])
+}
+
+func TestMaskCycle(t *testing.T) {
+	type node struct {
+		Name string `ddm:"full"`
+		Next *node
+	}
+	n := &node{Name: "abc"}
+	n.Next = n
+
+	m := Mask(n)
+	assert.Equal(t, "***", m.Name)
+	assert.Same(t, m, m.Next)
+}
+
+func TestMaskJSON(t *testing.T) {
+	b, err := MaskJSON(map[string]any{"u": user{Mobile: "13288887986"}})
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(b), `"mobile":"132****7986"`))
+
+	b, err = MaskJSON(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+	assert.Nil(t, Mask[any](nil))
+	assert.Nil(t, Mask[error](nil))
+	assert.Nil(t, Mask[*user](nil))
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("upper", func(v, _ string) string { return strings.ToUpper(v) })
+	type s struct {
+		V string `ddm:"upper"`
+	}
+	assert.Equal(t, "ABC", Mask(s{V: "abc"}).V)
+
+	masked, err := ApplyRule("keep=1,1", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "h***o", masked)
+	_, err = ApplyRule("unknown", "x")
+	assert.Error(t, err)
+}
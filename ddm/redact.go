@@ -0,0 +1,350 @@
+package ddm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/v-mars/library/logs"
+)
+
+// The kinds of data found by the built-in detectors of a Redactor.
+const (
+	DetectMobile   = "mobile"
+	DetectIDCard   = "id_card"
+	DetectBankCard = "bank_card"
+	DetectEmail    = "email"
+)
+
+type pattern struct {
+	re       *regexp.Regexp
+	validate func(string) bool
+	mask     func(string) string
+}
+
+// Redactor scrubs sensitive data out of free text such as log messages.
+// The built-in detectors find CN mobile numbers, 18-digit ID-card numbers
+// with a valid checksum, bank card numbers passing the Luhn check and
+// emails in a single pass without regular expressions.
+type Redactor struct {
+	mobile, idCard, bankCard, email bool
+	patterns                        []pattern
+}
+
+// RedactOption configures a Redactor.
+type RedactOption func(r *Redactor)
+
+// WithoutDetectors disables built-in detectors, e.g. DetectEmail.
+func WithoutDetectors(kinds ...string) RedactOption {
+	return func(r *Redactor) {
+		for _, k := range kinds {
+			switch k {
+			case DetectMobile:
+				r.mobile = false
+			case DetectIDCard:
+				r.idCard = false
+			case DetectBankCard:
+				r.bankCard = false
+			case DetectEmail:
+				r.email = false
+			}
+		}
+	}
+}
+
+// WithPattern adds a detector masking the matches of re for which validate,
+// if not nil, returns true. Patterns run after the built-in detectors.
+func WithPattern(re *regexp.Regexp, validate func(string) bool, mask func(string) string) RedactOption {
+	return func(r *Redactor) {
+		r.patterns = append(r.patterns, pattern{re: re, validate: validate, mask: mask})
+	}
+}
+
+// NewRedactor creates a Redactor with all built-in detectors enabled.
+func NewRedactor(opts ...RedactOption) *Redactor {
+	r := &Redactor{mobile: true, idCard: true, bankCard: true, email: true}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Redact returns s with the detected sensitive data masked.
+func (r *Redactor) Redact(s string) string {
+	out := r.scan(s)
+	for _, p := range r.patterns {
+		out = p.re.ReplaceAllStringFunc(out, func(m string) string {
+			if p.validate != nil && !p.validate(m) {
+				return m
+			}
+			return p.mask(m)
+		})
+	}
+	return out
+}
+
+// Sprintf formats like fmt.Sprintf after masking the tagged fields of
+// struct, map and slice arguments with Mask, then redacts the result.
+func (r *Redactor) Sprintf(format string, v ...any) string {
+	return r.Redact(fmt.Sprintf(format, maskArgs(v)...))
+}
+
+// Sprint is the fmt.Sprint counterpart of Sprintf.
+func (r *Redactor) Sprint(v ...any) string {
+	return r.Redact(fmt.Sprint(maskArgs(v)...))
+}
+
+func maskArgs(v []any) []any {
+	var out []any
+	for i, a := range v {
+		if a == nil || !needsWalk(reflect.TypeOf(a)) {
+			continue
+		}
+		if out == nil {
+			out = append([]any(nil), v...)
+		}
+		out[i] = Mask(a)
+	}
+	if out == nil {
+		return v
+	}
+	return out
+}
+
+func (r *Redactor) scan(s string) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	replace := func(start, end int, masked string) {
+		if b.Len() == 0 {
+			b.Grow(len(s))
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(masked)
+		last = end
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case isDigit(c):
+			j := i
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			end := j
+			if j-i == 17 && j < len(s) && (s[j] == 'X' || s[j] == 'x') {
+				end = j + 1
+			}
+			if (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+				if masked, ok := r.maskNumber(s[i:end]); ok {
+					replace(i, end, masked)
+					i = end
+					continue
+				}
+			}
+			i = j
+		case c == '@' && r.email:
+			start := i
+			for start > last && isEmailLocal(s[start-1]) {
+				start--
+			}
+			end := i + 1
+			for end < len(s) && isEmailDomain(s[end]) {
+				end++
+			}
+			for end > i+1 && s[end-1] == '.' {
+				end--
+			}
+			if start < i && strings.IndexByte(s[i+1:end], '.') > 0 {
+				replace(start, end, MaskEmail(s[start:end]))
+				i = end
+				continue
+			}
+			i++
+		default:
+			i++
+		}
+	}
+
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (r *Redactor) maskNumber(n string) (string, bool) {
+	switch {
+	case r.mobile && len(n) == 11 && n[0] == '1' && n[1] >= '3':
+		return MaskMobile(n), true
+	case r.idCard && len(n) == 18 && idCardChecksumOK(n):
+		return MaskIDCard(n), true
+	case r.bankCard && len(n) >= 16 && len(n) <= 19 && luhnOK(n):
+		return MaskBankCard(n), true
+	}
+	return "", false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isEmailLocal(c byte) bool {
+	return isWordByte(c) || c == '.' || c == '%' || c == '+' || c == '-'
+}
+
+func isEmailDomain(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '.' || c == '-'
+}
+
+// redactLogger masks and redacts every message before passing it to the
+// wrapped logger.
+type redactLogger struct {
+	logs.FullLogger
+	r *Redactor
+}
+
+// NewRedactLogger wraps l so that the arguments of every message are masked
+// with Mask, which applies the ddm struct tags, and the formatted message is
+// redacted by r, or by a Redactor with all built-in detectors when r is nil:
+//
+//	logs.SetLogger(ddm.NewRedactLogger(logs.DefaultLogger(), nil))
+//
+// The message is only formatted when l writes it, so disabled levels cost
+// nothing. When l is a logs.CallDepthLogger, as the default logger is, it
+// still reports the caller of the logging function.
+func NewRedactLogger(l logs.FullLogger, r *Redactor) logs.FullLogger {
+	if r == nil {
+		r = NewRedactor()
+	}
+	if cl, ok := l.(logs.CallDepthLogger); ok {
+		l = cl.WithCallDepth(1)
+	}
+	return &redactLogger{FullLogger: l, r: r}
+}
+
+// redacted formats and redacts a message when the logger prints it.
+type redacted struct {
+	r      *Redactor
+	format *string
+	v      []any
+}
+
+func (m redacted) String() string {
+	if m.format == nil {
+		return m.r.Sprint(m.v...)
+	}
+	return m.r.Sprintf(*m.format, m.v...)
+}
+
+func (l *redactLogger) msg(v []any) redacted { return redacted{r: l.r, v: v} }
+
+func (l *redactLogger) msgf(format string, v []any) redacted {
+	return redacted{r: l.r, format: &format, v: v}
+}
+
+func (l *redactLogger) Trace(v ...interface{})  { l.FullLogger.Trace(l.msg(v)) }
+func (l *redactLogger) Debug(v ...interface{})  { l.FullLogger.Debug(l.msg(v)) }
+func (l *redactLogger) Info(v ...interface{})   { l.FullLogger.Info(l.msg(v)) }
+func (l *redactLogger) Notice(v ...interface{}) { l.FullLogger.Notice(l.msg(v)) }
+func (l *redactLogger) Warn(v ...interface{})   { l.FullLogger.Warn(l.msg(v)) }
+func (l *redactLogger) Error(v ...interface{})  { l.FullLogger.Error(l.msg(v)) }
+func (l *redactLogger) Fatal(v ...interface{})  { l.FullLogger.Fatal(l.msg(v)) }
+
+func (l *redactLogger) Tracef(format string, v ...interface{}) {
+	l.FullLogger.Tracef("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) Debugf(format string, v ...interface{}) {
+	l.FullLogger.Debugf("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) Infof(format string, v ...interface{}) {
+	l.FullLogger.Infof("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) Noticef(format string, v ...interface{}) {
+	l.FullLogger.Noticef("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) Warnf(format string, v ...interface{}) {
+	l.FullLogger.Warnf("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) Errorf(format string, v ...interface{}) {
+	l.FullLogger.Errorf("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) Fatalf(format string, v ...interface{}) {
+	l.FullLogger.Fatalf("%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxTracef(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxTracef(ctx, "%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxDebugf(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxDebugf(ctx, "%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxInfof(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxInfof(ctx, "%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxNoticef(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxNoticef(ctx, "%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxWarnf(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxWarnf(ctx, "%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxErrorf(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxErrorf(ctx, "%s", l.msgf(format, v))
+}
+
+func (l *redactLogger) CtxFatalf(ctx context.Context, format string, v ...interface{}) {
+	l.FullLogger.CtxFatalf(ctx, "%s", l.msgf(format, v))
+}
+
+// redactWriter redacts every write before passing it to the wrapped writer.
+type redactWriter struct {
+	w io.Writer
+	r *Redactor
+}
+
+// NewRedactWriter returns a writer that redacts everything written to w
+// with r, or with a Redactor with all built-in detectors when r is nil.
+// It only runs the detectors of r, on the formatted output: use
+// NewRedactLogger for logs, which also masks the tagged fields of the
+// arguments and covers the loggers installed with logs.SetLogger.
+//
+// Each Write is redacted on its own. The standard library logger writes
+// one entry per call, so values are never split across writes.
+func NewRedactWriter(w io.Writer, r *Redactor) io.Writer {
+	if r == nil {
+		r = NewRedactor()
+	}
+	return &redactWriter{w: w, r: r}
+}
+
+func (rw *redactWriter) Write(p []byte) (int, error) {
+	s := string(p)
+	redacted := rw.r.Redact(s)
+	if redacted == s {
+		return rw.w.Write(p)
+	}
+	if _, err := io.WriteString(rw.w, redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,112 @@
+package ddm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/v-mars/library/logs"
+)
+
+func TestRedact(t *testing.T) {
+	r := NewRedactor()
+	cases := map[string]string{
+		"mobile=13288887986":                  "mobile=132****7986",
+		"手机号13288887986，请回电":                  "手机号132****7986，请回电",
+		"id 11010519491231002X end":           "id 1******X end",
+		"id 110105194912310021 bad checksum":  "id 110105194912310021 bad checksum",
+		"card:4111111111111111":               "card:411111******1111",
+		"card:4111111111111112":               "card:4111111111111112",
+		"mail xinliangnote@163.com.":          "mail x***e@163.com.",
+		"trace a13288887986 and 132888879861": "trace a13288887986 and 132888879861",
+		"no sensitive data":                   "no sensitive data",
+		"user@localhost":                      "user@localhost",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, r.Redact(in), in)
+	}
+
+	r = NewRedactor(WithoutDetectors(DetectEmail),
+		WithPattern(regexp.MustCompile(`sk-\w+`), nil, func(string) string { return "sk-***" }))
+	assert.Equal(t, "a@b.com sk-***", r.Redact("a@b.com sk-abcdef"))
+}
+
+func TestRedactSprintf(t *testing.T) {
+	type req struct {
+		Name  string `ddm:"name"`
+		Phone string
+	}
+	r := NewRedactor()
+	got := r.Sprintf("req=%+v", &req{Name: "李鸿章", Phone: "13288887986"})
+	assert.Equal(t, "req=&{Name:*鸿章 Phone:132****7986}", got)
+}
+
+func TestRedactLogger(t *testing.T) {
+	type req struct {
+		Name  string `ddm:"name"`
+		Phone string
+	}
+	var buf bytes.Buffer
+	base := logs.DefaultLogger()
+	base.SetOutput(&buf)
+	defer base.SetOutput(os.Stderr)
+	logs.SetLogger(NewRedactLogger(base, nil))
+	defer logs.SetLogger(base)
+
+	logs.CtxInfof(context.Background(), "call %s %d%% %+v", "13288887986", 100, req{Name: "李鸿章"})
+	logs.Info("mail ", "xinliangnote@163.com")
+
+	out := buf.String()
+	assert.True(t, strings.Contains(out, "call 132****7986 100% {Name:*鸿章 Phone:}"), out)
+	assert.True(t, strings.Contains(out, "mail x***e@163.com"), out)
+	assert.False(t, strings.Contains(out, "13288887986"), out)
+	// the caller is reported, not a frame of the redaction
+	assert.Equal(t, 2, strings.Count(out, "redact_test.go:"), out)
+
+	buf.Reset()
+	logs.SetLevel(logs.LevelWarn)
+	defer logs.SetLevel(logs.LevelInfo)
+	var formatted bool
+	logs.Infof("call %v", stringer(func() string { formatted = true; return "13288887986" }))
+	assert.Empty(t, buf.String())
+	assert.False(t, formatted, "disabled levels are not formatted")
+}
+
+type stringer func() string
+
+func (s stringer) String() string { return s() }
+
+func TestRedactWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewRedactWriter(&buf, nil)
+	n, err := w.Write([]byte("call 13288887986\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 17, n)
+	assert.Equal(t, "call 132****7986\n", buf.String())
+
+	n, err = w.Write([]byte("plain text"))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+}
+
+func BenchmarkRedact(b *testing.B) {
+	r := NewRedactor()
+	msg := "[handler] user 13288887986 id=11010519491231002X card=4111111111111111 email=xinliangnote@163.com status=200 cost=12ms"
+	plain := strings.Repeat("request finished without any sensitive data, status=200 ", 2)
+	b.Run("sensitive", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r.Redact(msg)
+		}
+	})
+	b.Run("plain", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r.Redact(plain)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package ddm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Rule masks value. param is the part of the tag after "=", e.g. "3,4"
+// for `ddm:"keep=3,4"`, and is empty when the tag has no parameter.
+type Rule func(value, param string) string
+
+var (
+	ruleMu sync.RWMutex
+	rules  = map[string]Rule{
+		"mobile":    func(v, _ string) string { return MaskMobile(v) },
+		"bank_card": func(v, _ string) string { return MaskBankCard(v) },
+		"id_card":   func(v, _ string) string { return MaskIDCard(v) },
+		"name":      func(v, _ string) string { return MaskName(v) },
+		"password":  func(v, _ string) string { return MaskPassword(v) },
+		"email":     func(v, _ string) string { return MaskEmail(v) },
+		"full":      func(v, _ string) string { return strings.Repeat("*", utf8.RuneCountInString(v)) },
+		"keep":      maskKeep,
+		"regex":     maskRegex,
+	}
+	regexCache sync.Map
+)
+
+// RegisterRule registers a masking rule usable in `ddm:"<name>"` tags. It
+// replaces the built-in rule of the same name.
+func RegisterRule(name string, rule Rule) {
+	ruleMu.Lock()
+	defer ruleMu.Unlock()
+	rules[name] = rule
+}
+
+func lookupRule(name string) (Rule, bool) {
+	ruleMu.RLock()
+	defer ruleMu.RUnlock()
+	r, ok := rules[name]
+	return r, ok
+}
+
+// ApplyRule masks value with a rule written like a tag, e.g. "keep=3,4".
+func ApplyRule(tag, value string) (string, error) {
+	name, param, _ := strings.Cut(tag, "=")
+	rule, ok := lookupRule(name)
+	if !ok {
+		return value, fmt.Errorf("ddm: unknown rule %q", name)
+	}
+	return rule(value, param), nil
+}
+
+// MaskMobile 手机号 132****7986
+func MaskMobile(m string) string {
+	if len(m) != 11 {
+		return m
+	}
+	return m[:3] + "****" + m[len(m)-4:]
+}
+
+// MaskBankCard 银行卡号 622888******5676
+func MaskBankCard(bc string) string {
+	if len(bc) > 19 || len(bc) < 16 {
+		return bc
+	}
+	return bc[:6] + "******" + bc[len(bc)-4:]
+}
+
+// MaskIDCard 身份证号 1******7
+func MaskIDCard(card string) string {
+	if len(card) != 18 {
+		return card
+	}
+	return card[:1] + "******" + card[len(card)-1:]
+}
+
+// MaskName 姓名 *鸿章
+func MaskName(name string) string {
+	if len(name) < 1 {
+		return ""
+	}
+	nameRune := []rune(name)
+	return "*" + string(nameRune[1:])
+}
+
+// MaskPassword 密码 ******
+func MaskPassword(string) string {
+	return "******"
+}
+
+// MaskEmail 邮箱 l***w@gmail.com
+func MaskEmail(e string) string {
+	if !strings.Contains(e, "@") {
+		return e
+	}
+
+	split := strings.Split(e, "@")
+	if len(split[0]) < 1 || len(split[1]) < 1 {
+		return e
+	}
+	return split[0][:1] + "***" + split[0][len(split[0])-1:] + "@" + split[1]
+}
+
+// maskKeep keeps the first and last runes given by param "head,tail" and
+// replaces the rest with '*'. Values too short to mask are fully masked.
+func maskKeep(value, param string) string {
+	headStr, tailStr, _ := strings.Cut(param, ",")
+	head, _ := strconv.Atoi(strings.TrimSpace(headStr))
+	tail, _ := strconv.Atoi(strings.TrimSpace(tailStr))
+
+	r := []rune(value)
+	if head < 0 || tail < 0 || head+tail >= len(r) {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:head]) + strings.Repeat("*", len(r)-head-tail) + string(r[len(r)-tail:])
+}
+
+// maskRegex masks the matches of the regular expression param. When the
+// expression has capture groups, only the groups are masked.
+func maskRegex(value, param string) string {
+	re, err := compileRegex(param)
+	if err != nil {
+		return strings.Repeat("*", utf8.RuneCountInString(value))
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(value, -1) {
+		spans := [][2]int{{m[0], m[1]}}
+		if len(m) > 2 {
+			spans = spans[:0]
+			for i := 2; i+1 < len(m); i += 2 {
+				if m[i] >= 0 {
+					spans = append(spans, [2]int{m[i], m[i+1]})
+				}
+			}
+		}
+		for _, s := range spans {
+			if s[0] < last {
+				continue
+			}
+			b.WriteString(value[last:s[0]])
+			b.WriteString(strings.Repeat("*", utf8.RuneCountInString(value[s[0]:s[1]])))
+			last = s[1]
+		}
+	}
+	b.WriteString(value[last:])
+	return b.String()
+}
+
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(expr, re)
+	return re, nil
+}
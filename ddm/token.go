@@ -0,0 +1,105 @@
+package ddm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrShortKey is returned when a tokenization key is shorter than 16 bytes.
+var ErrShortKey = errors.New("ddm: key must be at least 16 bytes")
+
+// Tokenizer derives stable, irreversible pseudonyms from sensitive values
+// with a secret key. The same key and value always give the same token, so
+// tokens can be used to join records, but the value cannot be recovered
+// from a token. Use a Vault when the value must be recoverable.
+type Tokenizer struct {
+	key []byte
+}
+
+// NewTokenizer creates a Tokenizer keyed with key.
+func NewTokenizer(key []byte) (*Tokenizer, error) {
+	if len(key) < 16 {
+		return nil, ErrShortKey
+	}
+	return &Tokenizer{key: append([]byte(nil), key...)}, nil
+}
+
+func (t *Tokenizer) mac(domain, kind, value string) []byte {
+	h := hmac.New(sha256.New, t.key)
+	h.Write([]byte(domain))
+	h.Write([]byte{0})
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
+
+// Token returns the HMAC-SHA256 token of value as 32 hex characters. kind
+// separates the token spaces of different data, e.g. "mobile" and "email".
+func (t *Tokenizer) Token(kind, value string) string {
+	return hex.EncodeToString(t.mac("token", kind, value)[:16])
+}
+
+// Pseudonym returns a deterministic pseudonym of value with the same length
+// and character classes: digits are replaced by digits, lowercase letters
+// by lowercase letters and uppercase letters by uppercase letters. Other
+// characters, such as "@" and "." in emails, are kept.
+func (t *Tokenizer) Pseudonym(kind, value string) string {
+	seed := t.mac("pseudonym", kind, value)
+	out := []byte(value)
+	var (
+		block   []byte
+		counter uint32
+	)
+	for i, c := range out {
+		var base, size byte
+		switch {
+		case c >= '0' && c <= '9':
+			base, size = '0', 10
+		case c >= 'a' && c <= 'z':
+			base, size = 'a', 26
+		case c >= 'A' && c <= 'Z':
+			base, size = 'A', 26
+		default:
+			continue
+		}
+		if len(block) < 2 {
+			block = t.expand(seed, counter)
+			counter++
+		}
+		r := binary.BigEndian.Uint16(block)
+		block = block[2:]
+		out[i] = base + byte(uint32(r)%uint32(size))
+	}
+	return string(out)
+}
+
+func (t *Tokenizer) expand(seed []byte, counter uint32) []byte {
+	h := hmac.New(sha256.New, t.key)
+	h.Write(seed)
+	var c [4]byte
+	binary.BigEndian.PutUint32(c[:], counter)
+	h.Write(c[:])
+	return h.Sum(nil)
+}
+
+// Tokenize returns the Token of a ddm value, using its type as kind.
+func Tokenize[T ~string](t *Tokenizer, v T) string {
+	return t.Token(fmt.Sprintf("%T", v), string(v))
+}
+
+// Pseudonymize returns the Pseudonym of a ddm value, using its type as kind.
+// A Mobile keeps its leading "1" so that the pseudonym still looks like a
+// mobile number.
+func Pseudonymize[T ~string](t *Tokenizer, v T) T {
+	s := string(v)
+	p := t.Pseudonym(fmt.Sprintf("%T", v), s)
+	if _, ok := any(v).(Mobile); ok && len(s) == 11 && s[0] == '1' {
+		p = s[:1] + p[1:]
+	}
+	return T(p)
+}
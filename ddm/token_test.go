@@ -0,0 +1,71 @@
+package ddm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestTokenizer(t *testing.T) {
+	_, err := NewTokenizer([]byte("short"))
+	assert.ErrorIs(t, err, ErrShortKey)
+
+	tk, err := NewTokenizer(testKey)
+	assert.NoError(t, err)
+
+	a := tk.Token("mobile", "13288887986")
+	assert.Len(t, a, 32)
+	assert.Equal(t, a, tk.Token("mobile", "13288887986"))
+	assert.NotEqual(t, a, tk.Token("id_card", "13288887986"))
+
+	other, _ := NewTokenizer([]byte("fedcba9876543210fedcba9876543210"))
+	assert.NotEqual(t, a, other.Token("mobile", "13288887986"))
+
+	p := tk.Pseudonym("email", "Xin.Liang99@163.com")
+	assert.Len(t, p, len("Xin.Liang99@163.com"))
+	assert.Regexp(t, `^[A-Z][a-z]{2}\.[A-Z][a-z]{4}[0-9]{2}@[0-9]{3}\.[a-z]{3}$`, p)
+	assert.Equal(t, p, tk.Pseudonym("email", "Xin.Liang99@163.com"))
+	assert.NotEqual(t, "Xin.Liang99@163.com", p)
+
+	m := Pseudonymize(tk, Mobile("13288887986"))
+	assert.Regexp(t, `^1[0-9]{10}$`, string(m))
+	assert.NotEqual(t, Mobile("13288887986"), m)
+	assert.Equal(t, Tokenize(tk, Mobile("13288887986")), Tokenize(tk, Mobile("13288887986")))
+	assert.NotEqual(t, Tokenize(tk, Mobile("13288887986")), Tokenize(tk, BankCard("13288887986")))
+}
+
+func TestVault(t *testing.T) {
+	v, err := NewVault(testKey)
+	assert.NoError(t, err)
+
+	token, err := v.Seal("id_card", "11010519491231002X")
+	assert.NoError(t, err)
+	again, _ := v.Seal("id_card", "11010519491231002X")
+	assert.Equal(t, token, again)
+
+	value, err := v.Open("id_card", token)
+	assert.NoError(t, err)
+	assert.Equal(t, "11010519491231002X", value)
+
+	_, err = v.Open("mobile", token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	tampered := []byte(token)
+	tampered[10] ^= 1
+	_, err = v.Open("id_card", string(tampered))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	other, _ := NewVault([]byte("fedcba9876543210fedcba9876543210"))
+	_, err = other.Open("id_card", token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	sealed, err := SealValue(v, Email("xinliangnote@163.com"))
+	assert.NoError(t, err)
+	email, err := OpenValue[Email](v, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, Email("xinliangnote@163.com"), email)
+	_, err = OpenValue[Mobile](v, sealed)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -19,3 +19,16 @@ type PassWord string
 
 // Email 邮箱 l***w@gmail.com
 type Email string
+
+// StrictMobile is a Mobile whose UnmarshalJSON rejects invalid numbers,
+// for request bodies. It is masked like Mobile.
+type StrictMobile Mobile
+
+// StrictBankCard is a BankCard whose UnmarshalJSON rejects invalid numbers.
+type StrictBankCard BankCard
+
+// StrictIDCard is an IDCard whose UnmarshalJSON rejects invalid numbers.
+type StrictIDCard IDCard
+
+// StrictEmail is an Email whose UnmarshalJSON rejects invalid addresses.
+type StrictEmail Email
@@ -1,6 +1,7 @@
 package ddm
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -0,0 +1,283 @@
+package ddm
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidMobile   = errors.New("ddm: invalid mobile")
+	ErrInvalidBankCard = errors.New("ddm: invalid bank card")
+	ErrInvalidIDCard   = errors.New("ddm: invalid id card")
+	ErrInvalidEmail    = errors.New("ddm: invalid email")
+)
+
+// Carrier is a CN mobile network operator.
+type Carrier string
+
+const (
+	CarrierChinaMobile   Carrier = "China Mobile"
+	CarrierChinaUnicom   Carrier = "China Unicom"
+	CarrierChinaTelecom  Carrier = "China Telecom"
+	CarrierChinaBroadnet Carrier = "China Broadnet"
+	CarrierVirtual       Carrier = "Virtual"
+)
+
+var carrierPrefixes = func() map[string]Carrier {
+	m := map[string]Carrier{}
+	add := func(c Carrier, prefixes ...string) {
+		for _, p := range prefixes {
+			m[p] = c
+		}
+	}
+	add(CarrierChinaMobile, "134", "135", "136", "137", "138", "139", "147", "148", "150", "151", "152",
+		"157", "158", "159", "172", "178", "182", "183", "184", "187", "188", "195", "197", "198")
+	add(CarrierChinaUnicom, "130", "131", "132", "145", "146", "155", "156", "166", "171", "175",
+		"176", "185", "186", "196")
+	add(CarrierChinaTelecom, "133", "1349", "149", "153", "173", "177", "180", "181", "189", "190",
+		"191", "193", "199")
+	add(CarrierChinaBroadnet, "192")
+	add(CarrierVirtual, "162", "165", "167", "170")
+	return m
+}()
+
+// MobileInfo is the result of ParseMobile.
+type MobileInfo struct {
+	Number  string
+	Carrier Carrier
+}
+
+// ParseMobile validates a CN mobile number against the carrier prefixes.
+// A leading "+86" or "86" country code is stripped.
+func ParseMobile(s string) (*MobileInfo, error) {
+	n := strings.TrimPrefix(strings.TrimPrefix(s, "+"), "86")
+	if len(n) != 11 || !allDigits(n) {
+		return nil, fmt.Errorf("%w: %q is not 11 digits", ErrInvalidMobile, s)
+	}
+	if c, ok := carrierPrefixes[n[:4]]; ok {
+		return &MobileInfo{Number: n, Carrier: c}, nil
+	}
+	if c, ok := carrierPrefixes[n[:3]]; ok {
+		return &MobileInfo{Number: n, Carrier: c}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown prefix %s", ErrInvalidMobile, n[:3])
+}
+
+// Validate checks the carrier prefix of the mobile number.
+func (m Mobile) Validate() error {
+	_, err := ParseMobile(string(m))
+	return err
+}
+
+var provinces = map[string]string{
+	"11": "北京", "12": "天津", "13": "河北", "14": "山西", "15": "内蒙古",
+	"21": "辽宁", "22": "吉林", "23": "黑龙江",
+	"31": "上海", "32": "江苏", "33": "浙江", "34": "安徽", "35": "福建", "36": "江西", "37": "山东",
+	"41": "河南", "42": "湖北", "43": "湖南", "44": "广东", "45": "广西", "46": "海南",
+	"50": "重庆", "51": "四川", "52": "贵州", "53": "云南", "54": "西藏",
+	"61": "陕西", "62": "甘肃", "63": "青海", "64": "宁夏", "65": "新疆",
+	"71": "台湾", "81": "香港", "82": "澳门",
+}
+
+// IDCardInfo is the result of ParseIDCard.
+type IDCardInfo struct {
+	Number     string
+	RegionCode string // the 6-digit administrative division code
+	Province   string
+	Birthday   time.Time
+	Male       bool
+}
+
+// ParseIDCard validates an 18-digit resident ID-card number (GB 11643):
+// the province code, the birth date and the check code.
+func ParseIDCard(s string) (*IDCardInfo, error) {
+	if len(s) != 18 {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidIDCard, len(s))
+	}
+	if !allDigits(s[:17]) {
+		return nil, fmt.Errorf("%w: non-digit in %q", ErrInvalidIDCard, s)
+	}
+
+	province, ok := provinces[s[:2]]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown region %s", ErrInvalidIDCard, s[:6])
+	}
+	birthday, err := time.Parse("20060102", s[6:14])
+	if err != nil || birthday.Year() < 1900 || birthday.After(time.Now()) {
+		return nil, fmt.Errorf("%w: bad birth date %s", ErrInvalidIDCard, s[6:14])
+	}
+	if !idCardChecksumOK(s) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidIDCard)
+	}
+
+	return &IDCardInfo{
+		Number:     strings.ToUpper(s),
+		RegionCode: s[:6],
+		Province:   province,
+		Birthday:   birthday,
+		Male:       (s[16]-'0')%2 == 1,
+	}, nil
+}
+
+// Validate checks the region, birth date and check code of the ID card.
+func (card IDCard) Validate() error {
+	_, err := ParseIDCard(string(card))
+	return err
+}
+
+//go:embed data/bin.csv
+var binCSV []byte
+
+// BINInfo is the issuer of a bank identification number.
+type BINInfo struct {
+	BIN      string
+	Issuer   string
+	CardType string
+}
+
+var (
+	binMu    sync.RWMutex
+	binTable = map[string]BINInfo{}
+)
+
+func init() {
+	sc := bufio.NewScanner(bytes.NewReader(binCSV))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 {
+			continue
+		}
+		binTable[fields[0]] = BINInfo{BIN: fields[0], Issuer: fields[1], CardType: fields[2]}
+	}
+}
+
+// RegisterBIN adds or replaces an entry of the BIN issuer table.
+func RegisterBIN(info BINInfo) {
+	binMu.Lock()
+	defer binMu.Unlock()
+	binTable[info.BIN] = info
+}
+
+// BankCardInfo is the result of ParseBankCard.
+type BankCardInfo struct {
+	Number  string
+	Network string
+	// Issuer is nil when the BIN is not in the table.
+	Issuer *BINInfo
+}
+
+// ParseBankCard validates a 16 to 19 digit bank card number with the Luhn
+// check and looks its issuer up by the longest matching BIN (6 to 8 digits).
+// Spaces and dashes are ignored.
+func ParseBankCard(s string) (*BankCardInfo, error) {
+	n := strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if len(n) < 16 || len(n) > 19 || !allDigits(n) {
+		return nil, fmt.Errorf("%w: %d digits", ErrInvalidBankCard, len(n))
+	}
+	if !luhnOK(n) {
+		return nil, fmt.Errorf("%w: luhn check failed", ErrInvalidBankCard)
+	}
+
+	info := &BankCardInfo{Number: n, Network: cardNetwork(n)}
+	binMu.RLock()
+	defer binMu.RUnlock()
+	for l := 8; l >= 6; l-- {
+		if b, ok := binTable[n[:l]]; ok {
+			info.Issuer = &b
+			break
+		}
+	}
+	return info, nil
+}
+
+// Validate checks the Luhn digit of the bank card.
+func (bc BankCard) Validate() error {
+	_, err := ParseBankCard(string(bc))
+	return err
+}
+
+func cardNetwork(n string) string {
+	switch {
+	case strings.HasPrefix(n, "62"):
+		return "UnionPay"
+	case n[0] == '4':
+		return "Visa"
+	case n[:2] >= "51" && n[:2] <= "55", n[:4] >= "2221" && n[:4] <= "2720":
+		return "Mastercard"
+	case n[:2] == "34", n[:2] == "37":
+		return "American Express"
+	case n[:2] == "35":
+		return "JCB"
+	}
+	return ""
+}
+
+// Validate checks that the email has a local part and a dotted domain.
+func (e Email) Validate() error {
+	local, domain, ok := strings.Cut(string(e), "@")
+	if !ok || local == "" || strings.Contains(domain, "@") ||
+		strings.IndexByte(domain, '.') <= 0 || strings.HasSuffix(domain, ".") {
+		return fmt.Errorf("%w: %q", ErrInvalidEmail, string(e))
+	}
+	return nil
+}
+
+func (m StrictMobile) Validate() error    { return Mobile(m).Validate() }
+func (bc StrictBankCard) Validate() error { return BankCard(bc).Validate() }
+func (card StrictIDCard) Validate() error { return IDCard(card).Validate() }
+func (e StrictEmail) Validate() error     { return Email(e).Validate() }
+
+func (m *StrictMobile) UnmarshalJSON(data []byte) error {
+	return unmarshalValidated(data, m)
+}
+
+func (bc *StrictBankCard) UnmarshalJSON(data []byte) error {
+	return unmarshalValidated(data, bc)
+}
+
+func (card *StrictIDCard) UnmarshalJSON(data []byte) error {
+	return unmarshalValidated(data, card)
+}
+
+func (e *StrictEmail) UnmarshalJSON(data []byte) error {
+	return unmarshalValidated(data, e)
+}
+
+// unmarshalValidated decodes a JSON string into v, rejecting non-empty
+// values that fail validation.
+func unmarshalValidated[T interface {
+	~string
+	Validate() error
+}](data []byte, v *T) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s != "" {
+		if err := T(s).Validate(); err != nil {
+			return err
+		}
+	}
+	*v = T(s)
+	return nil
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
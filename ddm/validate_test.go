@@ -0,0 +1,98 @@
+package ddm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIDCard(t *testing.T) {
+	info, err := ParseIDCard("11010519491231002x")
+	assert.NoError(t, err)
+	assert.Equal(t, "11010519491231002X", info.Number)
+	assert.Equal(t, "110105", info.RegionCode)
+	assert.Equal(t, "北京", info.Province)
+	assert.Equal(t, time.Date(1949, 12, 31, 0, 0, 0, 0, time.UTC), info.Birthday)
+	assert.False(t, info.Male)
+
+	for _, bad := range []string{"110105194912310021", "990105194912310021", "110105194913310021", "1101051949123100", "11010519491231002Y"} {
+		_, err = ParseIDCard(bad)
+		assert.ErrorIs(t, err, ErrInvalidIDCard, bad)
+	}
+}
+
+func TestParseBankCard(t *testing.T) {
+	info, err := ParseBankCard("6222 0210 0112 3456 789")
+	assert.NoError(t, err)
+	assert.Equal(t, "UnionPay", info.Network)
+	if assert.NotNil(t, info.Issuer) {
+		assert.Equal(t, "中国工商银行", info.Issuer.Issuer)
+	}
+
+	info, err = ParseBankCard("4111111111111111")
+	assert.NoError(t, err)
+	assert.Equal(t, "Visa", info.Network)
+	assert.Nil(t, info.Issuer)
+
+	RegisterBIN(BINInfo{BIN: "41111111", Issuer: "Test Bank", CardType: "credit"})
+	t.Cleanup(func() {
+		binMu.Lock()
+		defer binMu.Unlock()
+		delete(binTable, "41111111")
+	})
+	info, _ = ParseBankCard("4111111111111111")
+	assert.Equal(t, "Test Bank", info.Issuer.Issuer)
+
+	assert.ErrorIs(t, BankCard("4111111111111112").Validate(), ErrInvalidBankCard)
+	assert.ErrorIs(t, BankCard("411111").Validate(), ErrInvalidBankCard)
+}
+
+func TestParseMobile(t *testing.T) {
+	info, err := ParseMobile("+8613888887986")
+	assert.NoError(t, err)
+	assert.Equal(t, "13888887986", info.Number)
+	assert.Equal(t, CarrierChinaMobile, info.Carrier)
+
+	info, _ = ParseMobile("13498887986")
+	assert.Equal(t, CarrierChinaTelecom, info.Carrier)
+	info, _ = ParseMobile("13288887986")
+	assert.Equal(t, CarrierChinaUnicom, info.Carrier)
+
+	assert.ErrorIs(t, Mobile("12088887986").Validate(), ErrInvalidMobile)
+	assert.ErrorIs(t, Mobile("1328888798").Validate(), ErrInvalidMobile)
+}
+
+func TestStrictUnmarshal(t *testing.T) {
+	type form struct {
+		Mobile Mobile   `json:"mobile"`
+		Card   BankCard `json:"card"`
+		IDCard IDCard   `json:"id_card"`
+		Email  Email    `json:"email"`
+	}
+	type strictForm struct {
+		Mobile StrictMobile   `json:"mobile"`
+		Card   StrictBankCard `json:"card"`
+		IDCard StrictIDCard   `json:"id_card"`
+		Email  StrictEmail    `json:"email"`
+	}
+	bad := []byte(`{"mobile":"12088887986","card":"1","id_card":"x","email":"no"}`)
+
+	var f form
+	assert.NoError(t, json.Unmarshal(bad, &f))
+	assert.Equal(t, Mobile("12088887986"), f.Mobile)
+
+	var sf strictForm
+	assert.ErrorIs(t, json.Unmarshal(bad, &sf), ErrInvalidMobile)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"email":"no"}`), &sf), ErrInvalidEmail)
+	assert.NoError(t, json.Unmarshal([]byte(`{"mobile":""}`), &sf))
+
+	good := []byte(`{"mobile":"13288887986","card":"622588000012345675","id_card":"11010519491231002X","email":"a@b.com"}`)
+	assert.NoError(t, json.Unmarshal(good, &sf))
+	assert.Equal(t, StrictIDCard("11010519491231002X"), sf.IDCard)
+
+	// strict types are masked like the plain ones
+	out, _ := json.Marshal(sf)
+	assert.Contains(t, string(out), `"mobile":"132****7986"`)
+}
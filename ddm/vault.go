@@ -0,0 +1,112 @@
+package ddm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/v-mars/library/aes"
+)
+
+// ErrInvalidToken is returned by Vault.Open for tokens that were not sealed
+// by the vault's key or were tampered with.
+var ErrInvalidToken = errors.New("ddm: invalid vault token")
+
+const (
+	vaultPrefix = "vlt_"
+	vaultIVLen  = 16
+	vaultTagLen = 16
+)
+
+// Vault turns sensitive values into reversible tokens. Sealing is
+// deterministic, so equal values give equal tokens and can still be joined,
+// and only a holder of the key can Open a token. Tokens are AES-CBC
+// encrypted with the aes package under a synthetic IV derived from the
+// value, and authenticated with HMAC-SHA256.
+type Vault struct {
+	encKey []byte
+	macKey []byte
+}
+
+// NewVault creates a Vault. The encryption and MAC keys are derived from key.
+func NewVault(key []byte) (*Vault, error) {
+	if len(key) < 16 {
+		return nil, ErrShortKey
+	}
+	derive := func(label string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(label))
+		return h.Sum(nil)
+	}
+	return &Vault{encKey: derive("ddm vault enc"), macKey: derive("ddm vault mac")}, nil
+}
+
+func (v *Vault) mac(parts ...[]byte) []byte {
+	h := hmac.New(sha256.New, v.macKey)
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
+
+// Seal returns the token of value. kind separates the tokens of different
+// data, and the same kind must be passed to Open.
+func (v *Vault) Seal(kind, value string) (string, error) {
+	iv := v.mac([]byte("iv"), []byte(kind), []byte(value))[:vaultIVLen]
+	encrypted, err := aes.New(string(v.encKey), string(iv)).Encrypt(value)
+	if err != nil {
+		return "", err
+	}
+	ct, err := base64.URLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	raw := make([]byte, 0, vaultIVLen+len(ct)+vaultTagLen)
+	raw = append(raw, iv...)
+	raw = append(raw, ct...)
+	raw = append(raw, v.mac([]byte("tag"), []byte(kind), raw)[:vaultTagLen]...)
+	return vaultPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Open returns the value of a token sealed with the same key and kind.
+func (v *Vault) Open(kind, token string) (string, error) {
+	if !strings.HasPrefix(token, vaultPrefix) {
+		return "", ErrInvalidToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token[len(vaultPrefix):])
+	if err != nil || len(raw) < vaultIVLen+16+vaultTagLen || (len(raw)-vaultIVLen-vaultTagLen)%16 != 0 {
+		return "", ErrInvalidToken
+	}
+
+	body, tag := raw[:len(raw)-vaultTagLen], raw[len(raw)-vaultTagLen:]
+	if !hmac.Equal(tag, v.mac([]byte("tag"), []byte(kind), body)[:vaultTagLen]) {
+		return "", ErrInvalidToken
+	}
+
+	iv, ct := body[:vaultIVLen], body[vaultIVLen:]
+	value, err := aes.New(string(v.encKey), string(iv)).Decrypt(base64.URLEncoding.EncodeToString(ct))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return value, nil
+}
+
+// SealValue seals a ddm value, using its type as kind.
+func SealValue[T ~string](v *Vault, value T) (string, error) {
+	return v.Seal(fmt.Sprintf("%T", value), string(value))
+}
+
+// OpenValue opens a token sealed by SealValue into a ddm value of type T.
+func OpenValue[T ~string](v *Vault, token string) (T, error) {
+	var zero T
+	s, err := v.Open(fmt.Sprintf("%T", zero), token)
+	if err != nil {
+		return zero, err
+	}
+	return T(s), nil
+}
@@ -2,21 +2,16 @@ package goutil
 
 import (
 	"context"
-	"fmt"
-	"github.com/v-mars/library/logs"
-	"runtime/debug"
+	"github.com/v-mars/library/safego"
 )
 
+// Recovery recovers a panic, logs it and reports it to the panic handlers
+// registered with safego.RegisterPanicHandler.
 func Recovery(ctx context.Context) {
 	e := recover()
 	if e == nil {
 		return
 	}
 
-	if ctx == nil {
-		ctx = context.Background() // nolint: byted_context_not_reinitialize -- false positive
-	}
-
-	err := fmt.Errorf("%v", e)
-	logs.CtxErrorf(ctx, fmt.Sprintf("[catch panic] err = %v \n stacktrace:\n%s", err, debug.Stack()))
+	safego.HandlePanic(ctx, e)
 }
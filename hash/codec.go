@@ -0,0 +1,53 @@
+package hash
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrOutOfRange is returned when an ID cannot be encoded by a scheme.
+var ErrOutOfRange = errors.New("hash: id out of range")
+
+// Codec is a reversible ID obfuscation scheme. Hashids (New), Sqids
+// (NewSqids) and the Feistel permutation (NewFeistel) all implement it.
+type Codec interface {
+	// Encode 加密
+	Encode(ids ...uint64) (string, error)
+
+	// Decode 解密，无效的字符串返回 ErrInvalidHash
+	Decode(s string) ([]uint64, error)
+}
+
+var (
+	_ Codec = (*hash)(nil)
+	_ Codec = (*Sqids)(nil)
+	_ Codec = (*Feistel)(nil)
+)
+
+func (h *hash) Encode(ids ...uint64) (string, error) {
+	if len(ids) == 0 {
+		return "", ErrOutOfRange
+	}
+
+	nums := make([]int64, len(ids))
+	for i, id := range ids {
+		if id > math.MaxInt64 {
+			return "", ErrOutOfRange
+		}
+		nums[i] = int64(id)
+	}
+	return h.encode("", nums...)
+}
+
+func (h *hash) Decode(s string) ([]uint64, error) {
+	nums, _, err := h.decode("", s)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, len(nums))
+	for i, n := range nums {
+		ids[i] = uint64(n)
+	}
+	return ids, nil
+}
@@ -0,0 +1,133 @@
+package hash
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCodecs(t testing.TB) map[string]Codec {
+	sq, err := NewSqids(SqidsOptions{MinLength: 8})
+	assert.NoError(t, err)
+	fe, err := NewFeistel(secret)
+	assert.NoError(t, err)
+
+	return map[string]Codec{
+		"hashids": New(secret, length),
+		"sqids":   sq,
+		"feistel": fe,
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for name, c := range testCodecs(t) {
+		for _, id := range []uint64{0, 1, 2, 99, 1 << 40, math.MaxInt64} {
+			s, err := c.Encode(id)
+			assert.NoError(t, err, name)
+			got, err := c.Decode(s)
+			assert.NoError(t, err, name)
+			assert.Equal(t, []uint64{id}, got, name)
+		}
+
+		for _, bad := range []string{"", "!!!", "zzzzzzzzzzzzzzzzzzzzzzzzzzz"} {
+			_, err := c.Decode(bad)
+			assert.ErrorIs(t, err, ErrInvalidHash, "%s %q", name, bad)
+		}
+	}
+}
+
+func TestCodecSchemesDiffer(t *testing.T) {
+	codecs := testCodecs(t)
+	seen := map[string]string{}
+	for name, c := range codecs {
+		s, _ := c.Encode(12345)
+		for other, prev := range seen {
+			assert.NotEqual(t, prev, s, "%s and %s", name, other)
+		}
+		seen[name] = s
+
+		// an ID of one scheme does not decode under another
+		for otherName, other := range codecs {
+			if otherName == name {
+				continue
+			}
+			if ids, err := other.Decode(s); err == nil {
+				assert.NotEqual(t, []uint64{12345}, ids, "%s decoded by %s", name, otherName)
+			}
+		}
+	}
+}
+
+func TestSqidsSpec(t *testing.T) {
+	sq, _ := NewSqids(SqidsOptions{})
+	s, err := sq.Encode(1, 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "86Rf07", s)
+	ids, err := sq.Decode("86Rf07")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, ids)
+
+	padded, _ := NewSqids(SqidsOptions{MinLength: 10})
+	s, _ = padded.Encode(1, 2, 3)
+	assert.Equal(t, "86Rf07xd4z", s)
+
+	custom, _ := NewSqids(SqidsOptions{Alphabet: "FxnXM1kBN6cuhsAvjW3Co7l2RePyY8DwaU04Tzt9fHQrqSVKdpimLGIJOgb5ZE"})
+	s, _ = custom.Encode(1, 2, 3)
+	assert.Equal(t, "B4aajs", s)
+
+	blocked, _ := NewSqids(SqidsOptions{Blocklist: []string{"86Rf07"}})
+	s, _ = blocked.Encode(1, 2, 3)
+	assert.Equal(t, "se8ojk", s)
+	ids, err = blocked.Decode(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, ids)
+
+	s, err = sq.Encode(math.MaxUint64)
+	assert.NoError(t, err)
+	ids, _ = sq.Decode(s)
+	assert.Equal(t, []uint64{math.MaxUint64}, ids)
+
+	_, err = sq.Encode()
+	assert.ErrorIs(t, err, ErrOutOfRange)
+
+	_, err = NewSqids(SqidsOptions{Alphabet: "aab"})
+	assert.Error(t, err)
+}
+
+func TestFeistel(t *testing.T) {
+	f, _ := NewFeistel(secret)
+	prev := ""
+	for id := uint64(1); id < 100; id++ {
+		s, _ := f.Encode(id)
+		assert.Len(t, s, FeistelLength)
+		assert.NotEqual(t, prev, s)
+		prev = s
+		assert.Equal(t, id, f.Unpermute(f.Permute(id)))
+	}
+
+	s, _ := f.Encode(math.MaxUint64)
+	ids, err := f.Decode(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{math.MaxUint64}, ids)
+
+	other, _ := NewFeistel("another secret")
+	a, _ := f.Encode(42)
+	b, _ := other.Encode(42)
+	assert.NotEqual(t, a, b)
+
+	_, err = f.Encode(1, 2)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	for name, c := range testCodecs(b) {
+		s, _ := c.Encode(1234567890)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = c.Decode(s)
+			}
+		})
+	}
+}
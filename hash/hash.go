@@ -1,20 +1,52 @@
 package hash
 
+import (
+	"sync"
+
+	"github.com/speps/go-hashids"
+)
+
 var _ Hash = (*hash)(nil)
 
 type Hash interface {
 	i()
+	Codec
 
 	// HashidsEncode 加密
 	HashidsEncode(params []int) (string, error)
 
 	// HashidsDecode 解密
 	HashidsDecode(hash string) ([]int, error)
+
+	// HashidsDecodeWithSalt 解密，并返回匹配的 salt 下标，0 为当前 salt
+	HashidsDecodeWithSalt(hash string) ([]int, int, error)
+
+	// EncodeInt64 加密单个 int64 ID
+	EncodeInt64(id int64) (string, error)
+
+	// DecodeInt64 解密单个 int64 ID
+	DecodeInt64(hash string) (int64, error)
+
+	// EncodeUint64 加密单个 uint64 ID
+	EncodeUint64(id uint64) (string, error)
+
+	// DecodeUint64 解密单个 uint64 ID
+	DecodeUint64(hash string) (uint64, error)
+
+	// EncodeWithPrefix 加密带命名空间前缀的 ID，如 usr_xxx
+	EncodeWithPrefix(prefix string, id int64) (string, error)
+
+	// DecodeWithPrefix 解密带命名空间前缀的 ID，前缀不匹配时返回 ErrPrefixMismatch
+	DecodeWithPrefix(prefix, hash string) (int64, error)
 }
 
 type hash struct {
 	secret string
 	length int
+
+	// salts holds the previous secrets tried by decoding after secret
+	salts   []string
+	hashids sync.Map // salt -> *hashids.HashID
 }
 
 func New(secret string, length int) Hash {
@@ -24,4 +56,32 @@ func New(secret string, length int) Hash {
 	}
 }
 
+// NewWithSalts creates a Hash which encodes with secret and decodes with
+// secret, then each of previous in order, so that the IDs issued before a
+// secret rotation can still be decoded.
+func NewWithSalts(secret string, length int, previous ...string) Hash {
+	return &hash{
+		secret: secret,
+		length: length,
+		salts:  previous,
+	}
+}
+
 func (h *hash) i() {}
+
+func (h *hash) hashID(salt string) (*hashids.HashID, error) {
+	if v, ok := h.hashids.Load(salt); ok {
+		return v.(*hashids.HashID), nil
+	}
+
+	hd := hashids.NewData()
+	hd.Salt = salt
+	hd.MinLength = h.length
+	hid, err := hashids.NewWithData(hd)
+	if err != nil {
+		return nil, err
+	}
+
+	h.hashids.Store(salt, hid)
+	return hid, nil
+}
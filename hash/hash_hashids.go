@@ -1,15 +1,28 @@
 package hash
 
 import (
-	"github.com/speps/go-hashids"
+	"errors"
+	"math"
+	"strings"
 )
 
+var (
+	// ErrInvalidHash is returned when a hash matches none of the salts.
+	ErrInvalidHash = errors.New("hash: invalid hash")
+
+	// ErrPrefixMismatch is returned when a prefixed hash has another prefix.
+	ErrPrefixMismatch = errors.New("hash: prefix mismatch")
+)
+
+const prefixSep = "_"
+
 func (h *hash) HashidsEncode(params []int) (string, error) {
-	hd := hashids.NewData()
-	hd.Salt = h.secret
-	hd.MinLength = h.length
+	hid, err := h.hashID(h.secret)
+	if err != nil {
+		return "", err
+	}
 
-	hashStr, err := hashids.NewWithData(hd).Encode(params)
+	hashStr, err := hid.Encode(params)
 	if err != nil {
 		return "", err
 	}
@@ -18,14 +31,125 @@ func (h *hash) HashidsEncode(params []int) (string, error) {
 }
 
 func (h *hash) HashidsDecode(hash string) ([]int, error) {
-	hd := hashids.NewData()
-	hd.Salt = h.secret
-	hd.MinLength = h.length
-
-	ids, err := hashids.NewWithData(hd).DecodeWithError(hash)
+	ids, _, err := h.HashidsDecodeWithSalt(hash)
 	if err != nil {
 		return nil, err
 	}
 
 	return ids, nil
 }
+
+func (h *hash) HashidsDecodeWithSalt(hash string) ([]int, int, error) {
+	ids, salt, err := h.decode("", hash)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	result := make([]int, len(ids))
+	for i, id := range ids {
+		result[i] = int(id)
+	}
+	return result, salt, nil
+}
+
+// decode tries the current secret, then the previous ones, each suffixed
+// with namespace, and returns the index of the salt that matched.
+func (h *hash) decode(namespace, hash string) ([]int64, int, error) {
+	if hash == "" {
+		return nil, -1, ErrInvalidHash
+	}
+
+	salts := append([]string{h.secret}, h.salts...)
+	for i, salt := range salts {
+		hid, err := h.hashID(salt + namespace)
+		if err != nil {
+			return nil, -1, err
+		}
+		ids, err := hid.DecodeInt64WithError(hash)
+		if err == nil && len(ids) > 0 {
+			return ids, i, nil
+		}
+	}
+
+	return nil, -1, ErrInvalidHash
+}
+
+func (h *hash) encode(namespace string, ids ...int64) (string, error) {
+	hid, err := h.hashID(h.secret + namespace)
+	if err != nil {
+		return "", err
+	}
+
+	return hid.EncodeInt64(ids)
+}
+
+func (h *hash) EncodeInt64(id int64) (string, error) {
+	return h.encode("", id)
+}
+
+func (h *hash) DecodeInt64(hash string) (int64, error) {
+	ids, _, err := h.decode("", hash)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) != 1 {
+		return 0, ErrInvalidHash
+	}
+
+	return ids[0], nil
+}
+
+// EncodeUint64 encodes ids up to math.MaxInt64 like EncodeInt64, and larger
+// ones as their high and low 32 bits since Hashids only encodes int64.
+func (h *hash) EncodeUint64(id uint64) (string, error) {
+	if id <= math.MaxInt64 {
+		return h.encode("", int64(id))
+	}
+
+	return h.encode("", int64(id>>32), int64(id&math.MaxUint32))
+}
+
+func (h *hash) DecodeUint64(hash string) (uint64, error) {
+	ids, _, err := h.decode("", hash)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case len(ids) == 1:
+		return uint64(ids[0]), nil
+	case len(ids) == 2 && ids[0] >= 1<<31 && ids[0] <= math.MaxUint32 && ids[1] <= math.MaxUint32:
+		return uint64(ids[0])<<32 | uint64(ids[1]), nil
+	}
+
+	return 0, ErrInvalidHash
+}
+
+// EncodeWithPrefix encodes id as prefix + "_" + hash. The prefix is mixed
+// into the salt, so the same id gets a different hash in every namespace
+// and a hash cannot be moved to another prefix.
+func (h *hash) EncodeWithPrefix(prefix string, id int64) (string, error) {
+	hashStr, err := h.encode(prefixSep+prefix, id)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + prefixSep + hashStr, nil
+}
+
+func (h *hash) DecodeWithPrefix(prefix, hash string) (int64, error) {
+	body, ok := strings.CutPrefix(hash, prefix+prefixSep)
+	if !ok {
+		return 0, ErrPrefixMismatch
+	}
+
+	ids, _, err := h.decode(prefixSep+prefix, body)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) != 1 {
+		return 0, ErrInvalidHash
+	}
+
+	return ids[0], nil
+}
@@ -1,6 +1,12 @@
 package hash
 
-import "testing"
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 const secret = "i1ydX9RtHyuJTrw7frcu"
 const length = 12
@@ -16,3 +22,83 @@ func TestHashidsDecode(t *testing.T) {
 	ids, _ := New(secret, length).HashidsDecode("GyV5pJqXvwAR")
 	t.Log(ids)
 }
+
+func TestHashidsRoundTrip(t *testing.T) {
+	h := New(secret, length)
+	str, err := h.HashidsEncode([]int{99, 100})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(str), length)
+
+	ids, err := h.HashidsDecode(str)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{99, 100}, ids)
+
+	_, err = New("another secret", length).HashidsDecode(str)
+	assert.ErrorIs(t, err, ErrInvalidHash)
+}
+
+func TestSaltRotation(t *testing.T) {
+	old := New("old secret", length)
+	issued, _ := old.EncodeInt64(42)
+
+	rotated := NewWithSalts("new secret", length, "old secret")
+	ids, salt, err := rotated.HashidsDecodeWithSalt(issued)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{42}, ids)
+	assert.Equal(t, 1, salt)
+
+	fresh, _ := rotated.EncodeInt64(42)
+	assert.NotEqual(t, issued, fresh)
+	_, salt, err = rotated.HashidsDecodeWithSalt(fresh)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, salt)
+
+	_, err = old.DecodeInt64(fresh)
+	assert.ErrorIs(t, err, ErrInvalidHash)
+}
+
+func TestTypedIDs(t *testing.T) {
+	h := New(secret, length)
+
+	for _, id := range []int64{0, 1, math.MaxInt64} {
+		str, err := h.EncodeInt64(id)
+		assert.NoError(t, err)
+		got, err := h.DecodeInt64(str)
+		assert.NoError(t, err)
+		assert.Equal(t, id, got)
+	}
+
+	for _, id := range []uint64{0, math.MaxInt64, math.MaxInt64 + 1, math.MaxUint64} {
+		str, err := h.EncodeUint64(id)
+		assert.NoError(t, err)
+		got, err := h.DecodeUint64(str)
+		assert.NoError(t, err)
+		assert.Equal(t, id, got)
+	}
+
+	pair, _ := h.HashidsEncode([]int{1, 2})
+	_, err := h.DecodeInt64(pair)
+	assert.ErrorIs(t, err, ErrInvalidHash)
+	_, err = h.DecodeUint64(pair)
+	assert.ErrorIs(t, err, ErrInvalidHash)
+}
+
+func TestPrefixedIDs(t *testing.T) {
+	h := New(secret, length)
+
+	usr, err := h.EncodeWithPrefix("usr", 7)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(usr, "usr_"))
+
+	id, err := h.DecodeWithPrefix("usr", usr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+
+	_, err = h.DecodeWithPrefix("ord", usr)
+	assert.ErrorIs(t, err, ErrPrefixMismatch)
+
+	// the hash body of another namespace is rejected
+	ord, _ := h.EncodeWithPrefix("ord", 7)
+	_, err = h.DecodeWithPrefix("usr", "usr_"+strings.TrimPrefix(ord, "ord_"))
+	assert.ErrorIs(t, err, ErrInvalidHash)
+}
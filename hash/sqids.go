@@ -0,0 +1,244 @@
+package hash
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultSqidsAlphabet is the default alphabet of Sqids.
+const DefaultSqidsAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Sqids encodes IDs following the Sqids algorithm (https://sqids.org).
+//
+// The reference default blocklist is not bundled and the blocklist is empty
+// by default, so the IDs differ from those of the other Sqids
+// implementations with their default options whenever the reference would
+// have skipped a blocked word. Pass the same alphabet, minimum length and
+// blocklist as the other implementation to get interchangeable IDs.
+type Sqids struct {
+	alphabet  []byte
+	minLength int
+	blocklist []string
+}
+
+// SqidsOptions configures NewSqids.
+type SqidsOptions struct {
+	// Alphabet of at least 3 unique ASCII characters, DefaultSqidsAlphabet when empty.
+	Alphabet string
+	// MinLength pads the IDs to at least MinLength characters, at most 255.
+	MinLength int
+	// Blocklist words never appear in the generated IDs.
+	Blocklist []string
+}
+
+// NewSqids creates a Sqids encoder.
+func NewSqids(opts SqidsOptions) (*Sqids, error) {
+	alphabet := opts.Alphabet
+	if alphabet == "" {
+		alphabet = DefaultSqidsAlphabet
+	}
+	if len(alphabet) < 3 {
+		return nil, errors.New("hash: sqids alphabet must contain at least 3 characters")
+	}
+	seen := map[byte]bool{}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 {
+			return nil, errors.New("hash: sqids alphabet must be ASCII")
+		}
+		if seen[c] {
+			return nil, errors.New("hash: sqids alphabet must contain unique characters")
+		}
+		seen[c] = true
+	}
+	if opts.MinLength < 0 || opts.MinLength > 255 {
+		return nil, fmt.Errorf("hash: sqids min length must be between 0 and 255")
+	}
+
+	lower := strings.ToLower(alphabet)
+	var blocklist []string
+	for _, word := range opts.Blocklist {
+		if len(word) < 3 {
+			continue
+		}
+		word = strings.ToLower(word)
+		ok := true
+		for i := 0; i < len(word) && ok; i++ {
+			ok = strings.IndexByte(lower, word[i]) >= 0
+		}
+		if ok {
+			blocklist = append(blocklist, word)
+		}
+	}
+
+	return &Sqids{
+		alphabet:  sqidsShuffle([]byte(alphabet)),
+		minLength: opts.MinLength,
+		blocklist: blocklist,
+	}, nil
+}
+
+// Encode encodes one or more IDs.
+func (s *Sqids) Encode(ids ...uint64) (string, error) {
+	if len(ids) == 0 {
+		return "", ErrOutOfRange
+	}
+	return s.encode(ids, 0)
+}
+
+func (s *Sqids) encode(ids []uint64, increment int) (string, error) {
+	n := len(s.alphabet)
+	if increment > n {
+		return "", errors.New("hash: sqids reached max attempts to re-generate the id")
+	}
+
+	offset := len(ids)
+	for i, id := range ids {
+		offset += int(s.alphabet[id%uint64(n)]) + i
+	}
+	offset = (offset%n + increment) % n
+
+	alphabet := make([]byte, 0, n)
+	alphabet = append(alphabet, s.alphabet[offset:]...)
+	alphabet = append(alphabet, s.alphabet[:offset]...)
+	prefix := alphabet[0]
+	reverse(alphabet)
+
+	id := []byte{prefix}
+	for i, num := range ids {
+		id = append(id, sqidsToID(num, alphabet[1:])...)
+		if i < len(ids)-1 {
+			id = append(id, alphabet[0])
+			sqidsShuffle(alphabet)
+		}
+	}
+
+	if s.minLength > len(id) {
+		id = append(id, alphabet[0])
+		for s.minLength > len(id) {
+			sqidsShuffle(alphabet)
+			id = append(id, alphabet[:min(s.minLength-len(id), n)]...)
+		}
+	}
+
+	if s.isBlocked(string(id)) {
+		return s.encode(ids, increment+1)
+	}
+	return string(id), nil
+}
+
+func (s *Sqids) Decode(str string) ([]uint64, error) {
+	if str == "" {
+		return nil, ErrInvalidHash
+	}
+	for i := 0; i < len(str); i++ {
+		if indexByte(s.alphabet, str[i]) < 0 {
+			return nil, ErrInvalidHash
+		}
+	}
+
+	offset := indexByte(s.alphabet, str[0])
+	n := len(s.alphabet)
+	alphabet := make([]byte, 0, n)
+	alphabet = append(alphabet, s.alphabet[offset:]...)
+	alphabet = append(alphabet, s.alphabet[:offset]...)
+	reverse(alphabet)
+
+	var ids []uint64
+	rest := str[1:]
+	for rest != "" {
+		chunk, tail, found := strings.Cut(rest, string(alphabet[0]))
+		if chunk == "" {
+			break
+		}
+		id, ok := sqidsToNumber(chunk, alphabet[1:])
+		if !ok {
+			return nil, ErrInvalidHash
+		}
+		ids = append(ids, id)
+		if found {
+			sqidsShuffle(alphabet)
+		}
+		rest = tail
+	}
+
+	// reject non-canonical strings, such as a padded ID with extra characters
+	if canonical, err := s.Encode(ids...); err != nil || canonical != str {
+		return nil, ErrInvalidHash
+	}
+	return ids, nil
+}
+
+func (s *Sqids) isBlocked(id string) bool {
+	id = strings.ToLower(id)
+	for _, word := range s.blocklist {
+		if len(word) > len(id) {
+			continue
+		}
+		switch {
+		case len(id) <= 3 || len(word) <= 3:
+			if id == word {
+				return true
+			}
+		case strings.ContainsAny(word, "0123456789"):
+			if strings.HasPrefix(id, word) || strings.HasSuffix(id, word) {
+				return true
+			}
+		case strings.Contains(id, word):
+			return true
+		}
+	}
+	return false
+}
+
+func sqidsShuffle(chars []byte) []byte {
+	n := len(chars)
+	for i, j := 0, n-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(chars[i]) + int(chars[j])) % n
+		chars[i], chars[r] = chars[r], chars[i]
+	}
+	return chars
+}
+
+func sqidsToID(num uint64, alphabet []byte) []byte {
+	n := uint64(len(alphabet))
+	var id []byte
+	for {
+		id = append(id, alphabet[num%n])
+		num /= n
+		if num == 0 {
+			break
+		}
+	}
+	reverse(id)
+	return id
+}
+
+func sqidsToNumber(id string, alphabet []byte) (uint64, bool) {
+	n := uint64(len(alphabet))
+	var num uint64
+	for i := 0; i < len(id); i++ {
+		d := indexByte(alphabet, id[i])
+		if d < 0 || num > (^uint64(0)-uint64(d))/n {
+			return 0, false
+		}
+		num = num*n + uint64(d)
+	}
+	return num, true
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+func indexByte(b []byte, c byte) int {
+	for i, x := range b {
+		if x == c {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,314 @@
+package i18n
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CountArg is the placeholder that selects the plural form of a message.
+const CountArg = "count"
+
+// Message is a translation, with one form per plural category. A message
+// without plural forms only sets Other.
+type Message map[PluralCategory]string
+
+// Catalog holds the translations of several locales.
+type Catalog struct {
+	mu        sync.RWMutex
+	messages  map[Locale]map[string]Message
+	fallbacks map[Locale][]Locale
+	def       Locale
+}
+
+// NewCatalog creates an empty catalog which falls back to def.
+func NewCatalog(def Locale) *Catalog {
+	return &Catalog{
+		messages:  map[Locale]map[string]Message{},
+		fallbacks: map[Locale][]Locale{},
+		def:       def,
+	}
+}
+
+var defaultCatalog = func() *Catalog {
+	c := NewCatalog(LocaleEN)
+	c.SetFallback(LocaleZHTW, LocaleZH)
+	return c
+}()
+
+// DefaultCatalog returns the catalog used by T.
+func DefaultCatalog() *Catalog {
+	return defaultCatalog
+}
+
+// T translates key into the locale stored in ctx using the default catalog.
+func T(ctx context.Context, key string, args map[string]any) string {
+	return defaultCatalog.Translate(GetLocale(ctx), key, args)
+}
+
+// SetFallback sets the locales tried, in order, when a key is missing in
+// locale. The chain of each fallback is followed as well, and the default
+// locale is always tried last.
+func (c *Catalog) SetFallback(locale Locale, chain ...Locale) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fallbacks[locale] = chain
+}
+
+// Add adds or replaces the message of key in locale.
+func (c *Catalog) Add(locale Locale, key string, msg Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(locale, key, msg)
+}
+
+func (c *Catalog) add(locale Locale, key string, msg Message) {
+	m, ok := c.messages[locale]
+	if !ok {
+		m = map[string]Message{}
+		c.messages[locale] = m
+	}
+	m[key] = msg
+}
+
+// AddString adds a message of key in locale without plural forms.
+func (c *Catalog) AddString(locale Locale, key, msg string) {
+	c.Add(locale, key, Message{PluralOther: msg})
+}
+
+// LoadFS loads every .json, .yaml and .yml file of fsys matching patterns,
+// e.g. an embed.FS. The locale is taken from the file name: "zh-CN.json"
+// or "messages.zh-CN.yaml". Names are resolved with LookupLocale, so
+// "zh_CN.json" and "zh.json" load LocaleZH, and unknown names such as
+// "fr-FR.json" are registered with RegisterLocale, so that GetLocale and
+// T find them.
+func (c *Catalog) LoadFS(fsys fs.FS, patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+
+	for _, pattern := range patterns {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			ext := path.Ext(name)
+			if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			base := strings.TrimSuffix(path.Base(name), ext)
+			if i := strings.LastIndex(base, "."); i >= 0 {
+				base = base[i+1:]
+			}
+			locale, ok := LookupLocale(base)
+			if !ok {
+				locale = Locale(strings.ReplaceAll(base, "_", "-"))
+				RegisterLocale(locale)
+			}
+			if err = c.Load(locale, ext, data); err != nil {
+				return fmt.Errorf("i18n: load %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Load loads translations of locale encoded as JSON or YAML according to ext.
+// Nested objects are flattened into dotted keys, and an object whose keys
+// are all plural categories is a plural message.
+func (c *Catalog) Load(locale Locale, ext string, data []byte) error {
+	var raw map[string]any
+	var err error
+	switch strings.TrimPrefix(ext, ".") {
+	case "json":
+		err = json.Unmarshal(data, &raw)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		err = fmt.Errorf("unsupported format %q", ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flatten(locale, "", raw)
+}
+
+func (c *Catalog) flatten(locale Locale, prefix string, raw map[string]any) error {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch val := v.(type) {
+		case string:
+			c.add(locale, key, Message{PluralOther: val})
+		case map[string]any:
+			if msg, ok := pluralMessage(val); ok {
+				c.add(locale, key, msg)
+			} else if err := c.flatten(locale, key, val); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("key %q: unsupported value %T", key, v)
+		}
+	}
+	return nil
+}
+
+func pluralMessage(val map[string]any) (Message, bool) {
+	if len(val) == 0 {
+		return nil, false
+	}
+	msg := Message{}
+	for k, v := range val {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		switch cat := PluralCategory(k); cat {
+		case PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther:
+			msg[cat] = s
+		default:
+			return nil, false
+		}
+	}
+	return msg, true
+}
+
+// Has reports whether key is translated in locale, without fallback.
+func (c *Catalog) Has(locale Locale, key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.messages[locale][key]
+	return ok
+}
+
+// Locales returns the locales which have translations, sorted.
+func (c *Catalog) Locales() []Locale {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locales := make([]Locale, 0, len(c.messages))
+	for l := range c.messages {
+		locales = append(locales, l)
+	}
+	sort.Slice(locales, func(i, j int) bool { return locales[i] < locales[j] })
+	return locales
+}
+
+// Chain returns the locales tried when translating into locale.
+func (c *Catalog) Chain(locale Locale) []Locale {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.chain(locale)
+}
+
+func (c *Catalog) chain(locale Locale) []Locale {
+	var chain []Locale
+	seen := map[Locale]bool{}
+	var walk func(l Locale)
+	walk = func(l Locale) {
+		if seen[l] {
+			return
+		}
+		seen[l] = true
+		chain = append(chain, l)
+		for _, f := range c.fallbacks[l] {
+			walk(f)
+		}
+	}
+	walk(locale)
+	walk(c.def)
+	return chain
+}
+
+// Translate looks key up along the fallback chain of locale, selects the
+// plural form from args["count"] and replaces the {name} placeholders with
+// args. It returns key when no translation is found.
+func (c *Catalog) Translate(locale Locale, key string, args map[string]any) string {
+	c.mu.RLock()
+	var (
+		msg   Message
+		found Locale
+	)
+	for _, l := range c.chain(locale) {
+		if m, ok := c.messages[l][key]; ok {
+			msg, found = m, l
+			break
+		}
+	}
+	c.mu.RUnlock()
+
+	if msg == nil {
+		return key
+	}
+
+	text, ok := msg[PluralOther]
+	if count, has := args[CountArg]; has {
+		if s, exists := msg[PluralCategoryOf(found, count)]; exists {
+			text, ok = s, true
+		}
+	}
+	if !ok {
+		for _, cat := range []PluralCategory{PluralOne, PluralMany, PluralFew, PluralTwo, PluralZero} {
+			if text, ok = msg[cat]; ok {
+				break
+			}
+		}
+	}
+
+	return Format(text, args)
+}
+
+// Format replaces the {name} placeholders of text with args. Unknown
+// placeholders are kept, and "{{" and "}}" are literal braces.
+func Format(text string, args map[string]any) string {
+	if !strings.ContainsAny(text, "{}") {
+		return text
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == '{' && i+1 < len(text) && text[i+1] == '{':
+			b.WriteByte('{')
+			i++
+		case ch == '}' && i+1 < len(text) && text[i+1] == '}':
+			b.WriteByte('}')
+			i++
+		case ch == '{':
+			end := strings.IndexByte(text[i+1:], '}')
+			if end < 0 {
+				b.WriteString(text[i:])
+				return b.String()
+			}
+			name := text[i+1 : i+1+end]
+			if v, ok := args[name]; ok {
+				fmt.Fprint(&b, v)
+			} else {
+				b.WriteString(text[i : i+end+2])
+			}
+			i += end + 1
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFS = fstest.MapFS{
+	"locales/en-US.json": {Data: []byte(`{
+		"hello": "Hello, {name}!",
+		"inbox": {"messages": {"one": "You have {count} message", "other": "You have {count} messages"}},
+		"only_en": "English only"
+	}`)},
+	"locales/messages.zh-CN.yaml": {Data: []byte(`
+hello: "你好，{name}！"
+inbox:
+  messages: "你有 {count} 条消息"
+simplified: "简体"
+`)},
+	"locales/zh-TW.yml": {Data: []byte(`hello: "您好，{name}！"`)},
+	"locales/README.md": {Data: []byte(`ignored`)},
+}
+
+func TestCatalogTranslate(t *testing.T) {
+	c := NewCatalog(LocaleEN)
+	c.SetFallback(LocaleZHTW, LocaleZH)
+	assert.NoError(t, c.LoadFS(testFS, "locales/*"))
+	assert.Equal(t, []Locale{LocaleEN, LocaleZH, LocaleZHTW}, c.Locales())
+
+	args := map[string]any{"name": "Mars"}
+	assert.Equal(t, "Hello, Mars!", c.Translate(LocaleEN, "hello", args))
+	assert.Equal(t, "你好，Mars！", c.Translate(LocaleZH, "hello", args))
+	assert.Equal(t, "您好，Mars！", c.Translate(LocaleZHTW, "hello", args))
+
+	assert.Equal(t, []Locale{LocaleZHTW, LocaleZH, LocaleEN}, c.Chain(LocaleZHTW))
+	assert.Equal(t, "简体", c.Translate(LocaleZHTW, "simplified", nil))
+	assert.Equal(t, "English only", c.Translate(LocaleZHTW, "only_en", nil))
+	assert.Equal(t, "missing.key", c.Translate(LocaleZH, "missing.key", nil))
+
+	assert.Equal(t, "You have 1 message", c.Translate(LocaleEN, "inbox.messages", map[string]any{"count": 1}))
+	assert.Equal(t, "You have 2 messages", c.Translate(LocaleEN, "inbox.messages", map[string]any{"count": 2}))
+	assert.Equal(t, "You have 1.0 messages", c.Translate(LocaleEN, "inbox.messages", map[string]any{"count": "1.0"}))
+	assert.Equal(t, "你有 1 条消息", c.Translate(LocaleZH, "inbox.messages", map[string]any{"count": 1}))
+}
+
+func TestLoadFSLocales(t *testing.T) {
+	c := NewCatalog(LocaleEN)
+	assert.NoError(t, c.LoadFS(fstest.MapFS{
+		"zh_CN.json": {Data: []byte(`{"hi": "你好"}`)},
+		"fr_FR.json": {Data: []byte(`{"hi": "Bonjour"}`)},
+	}))
+	assert.Equal(t, "你好", c.Translate(LocaleZH, "hi", nil))
+
+	fr, ok := LookupLocale("fr-fr")
+	if assert.True(t, ok) {
+		assert.Equal(t, Locale("fr-FR"), fr)
+	}
+	ctx := SetLocale(context.Background(), "fr_FR")
+	assert.Equal(t, fr, GetLocale(ctx))
+	assert.Equal(t, "Bonjour", c.Translate(GetLocale(ctx), "hi", nil))
+}
+
+func TestT(t *testing.T) {
+	DefaultCatalog().AddString(LocaleZH, "test.t", "测试 {n}")
+	DefaultCatalog().AddString(LocaleEN, "test.t", "test {n}")
+
+	ctx := SetLocale(context.Background(), "zh-TW")
+	assert.Equal(t, "测试 1", T(ctx, "test.t", map[string]any{"n": 1}))
+	assert.Equal(t, "test 1", T(context.Background(), "test.t", map[string]any{"n": 1}))
+}
+
+func TestFormat(t *testing.T) {
+	args := map[string]any{"a": 1, "b": "x"}
+	assert.Equal(t, "1-x", Format("{a}-{b}", args))
+	assert.Equal(t, "{c} {a}", Format("{c} {{a}}", args))
+	assert.Equal(t, "open {a", Format("open {a", args))
+}
+
+func TestPluralCategoryOf(t *testing.T) {
+	cases := []struct {
+		locale Locale
+		n      any
+		want   PluralCategory
+	}{
+		{LocaleEN, 1, PluralOne},
+		{LocaleEN, 0, PluralOther},
+		{LocaleEN, 1.5, PluralOther},
+		{LocaleZH, 1, PluralOther},
+		{"fr-FR", 0, PluralOne},
+		{"fr-FR", 1.5, PluralOne},
+		{"ru-RU", 21, PluralOne},
+		{"ru-RU", 22, PluralFew},
+		{"ru-RU", 12, PluralMany},
+		{"pl-PL", 1, PluralOne},
+		{"pl-PL", 22, PluralFew},
+		{"pl-PL", 21, PluralMany},
+		{"ar", 0, PluralZero},
+		{"ar", 2, PluralTwo},
+		{"ar", 105, PluralFew},
+		{"ar", 111, PluralMany},
+		{"cs", 3, PluralFew},
+		{LocaleEN, struct{}{}, PluralOther},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, PluralCategoryOf(c.locale, c.n), "%s %v", c.locale, c.n)
+	}
+}
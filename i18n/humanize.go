@@ -0,0 +1,264 @@
+package i18n
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v-mars/library/units"
+)
+
+// NumberSymbols are the decimal and group separators of a language.
+type NumberSymbols struct {
+	Decimal string
+	Group   string
+}
+
+// DateLayouts are the time layouts of a locale used by FormatDate.
+type DateLayouts struct {
+	Short    string
+	Long     string
+	DateTime string
+}
+
+// dateTimeLayout is the ISO date and time layout, timeutil.CSTLayout.
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// DateStyle selects a layout of DateLayouts.
+type DateStyle int
+
+const (
+	DateShort DateStyle = iota
+	DateLong
+	DateTime
+)
+
+// LargeUnit is a large number unit, e.g. 1e4 "万".
+type LargeUnit struct {
+	Value  float64
+	Suffix string
+}
+
+var (
+	formatMu      sync.RWMutex
+	numberSymbols = map[string]NumberSymbols{
+		"de": {Decimal: ",", Group: "."},
+		"es": {Decimal: ",", Group: "."},
+		"it": {Decimal: ",", Group: "."},
+		"nl": {Decimal: ",", Group: "."},
+		"pt": {Decimal: ",", Group: "."},
+		"fr": {Decimal: ",", Group: " "},
+		"ru": {Decimal: ",", Group: " "},
+	}
+	dateLayouts = map[Locale]DateLayouts{
+		LocaleEN:   {Short: "01/02/2006", Long: "January 2, 2006", DateTime: "Jan 2, 2006 3:04:05 PM"},
+		LocaleZH:   {Short: "2006/01/02", Long: "2006年1月2日", DateTime: dateTimeLayout},
+		LocaleZHTW: {Short: "2006/01/02", Long: "2006年1月2日", DateTime: dateTimeLayout},
+	}
+	largeUnits = map[Locale][]LargeUnit{
+		LocaleEN:   {{1e12, "T"}, {1e9, "B"}, {1e6, "M"}, {1e3, "K"}},
+		LocaleZH:   {{1e12, "万亿"}, {1e8, "亿"}, {1e4, "万"}},
+		LocaleZHTW: {{1e12, "兆"}, {1e8, "億"}, {1e4, "萬"}},
+	}
+	defaultNumberSymbols = NumberSymbols{Decimal: ".", Group: ","}
+	defaultDateLayouts   = DateLayouts{Short: "2006-01-02", Long: "2006-01-02", DateTime: dateTimeLayout}
+)
+
+func init() {
+	durations := map[Locale]map[units.DurationUnit]Message{
+		LocaleEN: {
+			units.DurationSecond: {PluralOne: "{count} second", PluralOther: "{count} seconds"},
+			units.DurationMinute: {PluralOne: "{count} minute", PluralOther: "{count} minutes"},
+			units.DurationHour:   {PluralOne: "{count} hour", PluralOther: "{count} hours"},
+			units.DurationDay:    {PluralOne: "{count} day", PluralOther: "{count} days"},
+			units.DurationWeek:   {PluralOne: "{count} week", PluralOther: "{count} weeks"},
+			units.DurationMonth:  {PluralOne: "{count} month", PluralOther: "{count} months"},
+			units.DurationYear:   {PluralOne: "{count} year", PluralOther: "{count} years"},
+		},
+		LocaleZH: {
+			units.DurationSecond: {PluralOther: "{count}秒"},
+			units.DurationMinute: {PluralOther: "{count}分钟"},
+			units.DurationHour:   {PluralOther: "{count}小时"},
+			units.DurationDay:    {PluralOther: "{count}天"},
+			units.DurationWeek:   {PluralOther: "{count}周"},
+			units.DurationMonth:  {PluralOther: "{count}个月"},
+			units.DurationYear:   {PluralOther: "{count}年"},
+		},
+		LocaleZHTW: {
+			units.DurationSecond: {PluralOther: "{count}秒"},
+			units.DurationMinute: {PluralOther: "{count}分鐘"},
+			units.DurationHour:   {PluralOther: "{count}小時"},
+			units.DurationDay:    {PluralOther: "{count}天"},
+			units.DurationWeek:   {PluralOther: "{count}週"},
+			units.DurationMonth:  {PluralOther: "{count}個月"},
+			units.DurationYear:   {PluralOther: "{count}年"},
+		},
+	}
+	for locale, msgs := range durations {
+		for unit, msg := range msgs {
+			defaultCatalog.Add(locale, "i18n.duration."+string(unit), msg)
+		}
+	}
+
+	for locale, msgs := range map[Locale][4]string{
+		LocaleEN:   {"just now", "{duration} ago", "in {duration}", "less than a second"},
+		LocaleZH:   {"刚刚", "{duration}前", "{duration}后", "不到1秒"},
+		LocaleZHTW: {"剛剛", "{duration}前", "{duration}後", "不到1秒"},
+	} {
+		defaultCatalog.AddString(locale, "i18n.relative.now", msgs[0])
+		defaultCatalog.AddString(locale, "i18n.relative.past", msgs[1])
+		defaultCatalog.AddString(locale, "i18n.relative.future", msgs[2])
+		defaultCatalog.AddString(locale, "i18n.duration.zero", msgs[3])
+	}
+}
+
+// RegisterNumberSymbols sets the separators of a language, e.g. "de".
+func RegisterNumberSymbols(lang string, symbols NumberSymbols) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	numberSymbols[strings.ToLower(lang)] = symbols
+}
+
+// RegisterDateLayouts sets the date layouts of locale.
+func RegisterDateLayouts(locale Locale, layouts DateLayouts) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	dateLayouts[locale] = layouts
+}
+
+// RegisterLargeUnits sets the large number units of locale, largest first.
+func RegisterLargeUnits(locale Locale, list []LargeUnit) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	largeUnits[locale] = list
+}
+
+func language(locale Locale) string {
+	lang := strings.ToLower(string(locale))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+func symbolsOf(locale Locale) NumberSymbols {
+	formatMu.RLock()
+	defer formatMu.RUnlock()
+	if s, ok := numberSymbols[language(locale)]; ok {
+		return s
+	}
+	return defaultNumberSymbols
+}
+
+// FormatNumber formats n with precision decimals and the group and decimal
+// separators of the locale in ctx (eg. "1,234,567.89").
+// A negative precision uses the fewest decimals needed.
+func FormatNumber(ctx context.Context, n float64, precision int) string {
+	return formatNumber(symbolsOf(GetLocale(ctx)), n, precision)
+}
+
+func formatNumber(sym NumberSymbols, n float64, precision int) string {
+	s := strconv.FormatFloat(n, 'f', precision, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(sym.Group)
+		}
+		b.WriteRune(ch)
+	}
+	if frac != "" {
+		b.WriteString(sym.Decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// FormatLargeNumber abbreviates n with the large number units of the locale
+// in ctx, keeping at most precision decimals (eg. "1.5万", "2.3M").
+// Numbers below the smallest unit are formatted by FormatNumber.
+func FormatLargeNumber(ctx context.Context, n float64, precision int) string {
+	locale := GetLocale(ctx)
+	sym := symbolsOf(locale)
+
+	formatMu.RLock()
+	list := largeUnits[locale]
+	formatMu.RUnlock()
+
+	for _, u := range list {
+		if math.Abs(n) >= u.Value {
+			s := strconv.FormatFloat(n/u.Value, 'f', precision, 64)
+			if strings.Contains(s, ".") {
+				s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+			}
+			return strings.Replace(s, ".", sym.Decimal, 1) + u.Suffix
+		}
+	}
+	return formatNumber(sym, n, -1)
+}
+
+// FormatSize returns the units.HumanSize of size with the decimal separator
+// of the locale in ctx.
+func FormatSize(ctx context.Context, size float64) string {
+	return strings.Replace(units.HumanSize(size), ".", symbolsOf(GetLocale(ctx)).Decimal, 1)
+}
+
+// FormatDuration returns the units.ApproxDuration of d in the locale of ctx
+// (eg. "5 minutes", "3天").
+func FormatDuration(ctx context.Context, d time.Duration) string {
+	locale := GetLocale(ctx)
+	n, unit := units.ApproxDuration(d)
+	if n == 0 && unit == units.DurationSecond {
+		return defaultCatalog.Translate(locale, "i18n.duration.zero", nil)
+	}
+	return defaultCatalog.Translate(locale, "i18n.duration."+string(unit), map[string]any{CountArg: n})
+}
+
+// RelativeTime describes t relative to now in the locale of ctx
+// (eg. "5 minutes ago", "5分钟前", "in 2 days").
+func RelativeTime(ctx context.Context, t time.Time) string {
+	return relativeTime(ctx, t, time.Now())
+}
+
+func relativeTime(ctx context.Context, t, now time.Time) string {
+	locale := GetLocale(ctx)
+	d := now.Sub(t)
+	key := "i18n.relative.past"
+	if d < 0 {
+		d, key = -d, "i18n.relative.future"
+	}
+	if d < time.Second {
+		return defaultCatalog.Translate(locale, "i18n.relative.now", nil)
+	}
+	return defaultCatalog.Translate(locale, key, map[string]any{"duration": FormatDuration(ctx, d)})
+}
+
+// FormatDate formats t, in its own location, with the layout of style of
+// the locale in ctx. Convert t with In first to show another time zone.
+// Locales without registered layouts use ISO dates and times.
+func FormatDate(ctx context.Context, t time.Time, style DateStyle) string {
+	formatMu.RLock()
+	layouts, ok := dateLayouts[GetLocale(ctx)]
+	formatMu.RUnlock()
+	if !ok {
+		layouts = defaultDateLayouts
+	}
+
+	switch style {
+	case DateLong:
+		return t.Format(layouts.Long)
+	case DateTime:
+		return t.Format(layouts.DateTime)
+	default:
+		return t.Format(layouts.Short)
+	}
+}
@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ctxEN   = SetLocale(context.Background(), "en-US")
+	ctxZH   = SetLocale(context.Background(), "zh-CN")
+	ctxZHTW = SetLocale(context.Background(), "zh-TW")
+)
+
+func TestFormatNumber(t *testing.T) {
+	assert.Equal(t, "1,234,567.89", FormatNumber(ctxEN, 1234567.891, 2))
+	assert.Equal(t, "-1,000", FormatNumber(ctxEN, -1000, 0))
+	assert.Equal(t, "999", FormatNumber(ctxZH, 999, -1))
+
+	RegisterLocale("de-DE")
+	ctxDE := SetLocale(context.Background(), "de-DE")
+	assert.Equal(t, "1.234.567,5", FormatNumber(ctxDE, 1234567.5, -1))
+	assert.Equal(t, "1,5", FormatSize(ctxDE, 1500)[:3])
+}
+
+func TestFormatLargeNumber(t *testing.T) {
+	assert.Equal(t, "1.5万", FormatLargeNumber(ctxZH, 15000, 2))
+	assert.Equal(t, "3亿", FormatLargeNumber(ctxZH, 3e8, 2))
+	assert.Equal(t, "1.23萬", FormatLargeNumber(ctxZHTW, 12345, 2))
+	assert.Equal(t, "2.3M", FormatLargeNumber(ctxEN, 2.3e6, 1))
+	assert.Equal(t, "9,999", FormatLargeNumber(ctxZH, 9999, 2))
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "5 minutes", FormatDuration(ctxEN, 5*time.Minute))
+	assert.Equal(t, "1 hour", FormatDuration(ctxEN, time.Hour))
+	assert.Equal(t, "3天", FormatDuration(ctxZH, 3*24*time.Hour))
+	assert.Equal(t, "2小時", FormatDuration(ctxZHTW, 2*time.Hour))
+	assert.Equal(t, "less than a second", FormatDuration(ctxEN, 0))
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, "5 minutes ago", relativeTime(ctxEN, now.Add(-5*time.Minute), now))
+	assert.Equal(t, "5分钟前", relativeTime(ctxZH, now.Add(-5*time.Minute), now))
+	assert.Equal(t, "3天前", relativeTime(ctxZH, now.Add(-3*24*time.Hour), now))
+	assert.Equal(t, "in 2 days", relativeTime(ctxEN, now.Add(48*time.Hour), now))
+	assert.Equal(t, "刚刚", relativeTime(ctxZH, now, now))
+}
+
+func TestFormatDate(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 4, 5, 0, time.FixedZone("CST", 8*3600))
+	assert.Equal(t, "03/05/2024", FormatDate(ctxEN, ts, DateShort))
+	assert.Equal(t, "March 5, 2024", FormatDate(ctxEN, ts, DateLong))
+	assert.Equal(t, "Mar 5, 2024 2:04:05 PM", FormatDate(ctxEN, ts, DateTime))
+	assert.Equal(t, "2024年3月5日", FormatDate(ctxZH, ts, DateLong))
+	assert.Equal(t, "2024-03-05 14:04:05", FormatDate(ctxZH, ts, DateTime))
+
+	// every style uses the location of t
+	late := time.Date(2024, 3, 5, 20, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2024/03/05", FormatDate(ctxZH, late, DateShort))
+	assert.Equal(t, "2024-03-05 20:00:00", FormatDate(ctxZH, late, DateTime))
+	assert.Equal(t, "2024-03-06 04:00:00", FormatDate(ctxZH, late.In(ts.Location()), DateTime))
+}
@@ -2,33 +2,86 @@ package i18n
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"sync"
 )
 
 type Locale string
 
 const (
-	LocaleEN Locale = "en-US"
-	LocaleZH Locale = "zh-CN"
+	LocaleEN   Locale = "en-US"
+	LocaleZH   Locale = "zh-CN"
+	LocaleZHTW Locale = "zh-TW"
 )
 
 const key = "i18n.locale.key"
 
+var (
+	localeMu sync.RWMutex
+	locales  = map[string]Locale{}
+)
+
+func init() {
+	RegisterLocale(LocaleEN, "en")
+	RegisterLocale(LocaleZH, "zh", "zh-Hans", "zh-Hans-CN", "zh-SG")
+	RegisterLocale(LocaleZHTW, "zh-Hant", "zh-Hant-TW", "zh-HK", "zh-MO")
+}
+
+// RegisterLocale registers a locale recognised by GetLocale, with optional
+// aliases that map to it. Tags are matched case-insensitively and "_" is
+// treated as "-".
+func RegisterLocale(locale Locale, aliases ...string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	locales[normalizeTag(string(locale))] = locale
+	for _, alias := range aliases {
+		locales[normalizeTag(alias)] = locale
+	}
+}
+
+// LookupLocale returns the registered locale of tag.
+func LookupLocale(tag string) (Locale, bool) {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	l, ok := locales[normalizeTag(tag)]
+	return l, ok
+}
+
+// RegisteredLocales returns the registered locales, sorted.
+func RegisteredLocales() []Locale {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	seen := map[Locale]bool{}
+	list := make([]Locale, 0, len(locales))
+	for _, l := range locales {
+		if !seen[l] {
+			seen[l] = true
+			list = append(list, l)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
 func SetLocale(ctx context.Context, locale string) context.Context {
 	return context.WithValue(ctx, key, locale)
 }
 
+// GetLocale returns the locale stored in ctx, or LocaleEN when it is unset
+// or not registered.
 func GetLocale(ctx context.Context) Locale {
-	locale := ctx.Value(key)
-	if locale == nil {
+	locale, ok := ctx.Value(key).(string)
+	if !ok {
 		return LocaleEN
 	}
 
-	switch locale.(string) {
-	case "en-US":
-		return LocaleEN
-	case "zh-CN":
-		return LocaleZH
-	default:
-		return LocaleEN
+	if l, ok := LookupLocale(locale); ok {
+		return l
 	}
+	return LocaleEN
 }
@@ -0,0 +1,178 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LanguageRange is a language range of an Accept-Language header with its
+// quality value.
+type LanguageRange struct {
+	Tag string
+	Q   float64
+}
+
+// ParseAcceptLanguage parses an Accept-Language header (RFC 9110 section
+// 12.5.4) into language ranges sorted by descending quality. Ranges with
+// q=0 or a malformed q-value are dropped.
+func ParseAcceptLanguage(header string) []LanguageRange {
+	var ranges []LanguageRange
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || v < 0 || v > 1 {
+				q = 0
+			} else {
+				q = v
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, LanguageRange{Tag: tag, Q: q})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].Q > ranges[j].Q })
+	return ranges
+}
+
+// Negotiate picks the best of supported for ranges using RFC 4647 lookup:
+// each range is progressively truncated ("zh-Hant-TW", "zh-Hant", "zh")
+// until it matches a supported locale, a registered alias of one, or the
+// language of one. "*" matches the first supported locale.
+func Negotiate(ranges []LanguageRange, supported []Locale) (Locale, bool) {
+	if len(supported) == 0 {
+		supported = RegisteredLocales()
+	}
+
+	for _, r := range ranges {
+		if r.Tag == "*" {
+			if len(supported) > 0 {
+				return supported[0], true
+			}
+			continue
+		}
+
+		tag := normalizeTag(r.Tag)
+		for tag != "" {
+			if l, ok := matchLocale(tag, supported); ok {
+				return l, true
+			}
+			i := strings.LastIndexByte(tag, '-')
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+			// a single-letter subtag is not a valid truncation point
+			if len(tag) > 2 && tag[len(tag)-2] == '-' {
+				tag = tag[:len(tag)-2]
+			}
+		}
+	}
+	return "", false
+}
+
+func matchLocale(tag string, supported []Locale) (Locale, bool) {
+	for _, l := range supported {
+		if normalizeTag(string(l)) == tag {
+			return l, true
+		}
+	}
+	if alias, ok := LookupLocale(tag); ok {
+		for _, l := range supported {
+			if l == alias {
+				return l, true
+			}
+		}
+	}
+	for _, l := range supported {
+		if strings.HasPrefix(normalizeTag(string(l)), tag+"-") {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Negotiator negotiates a locale for an HTTP request.
+type Negotiator struct {
+	// Supported locales, all registered locales when empty.
+	Supported []Locale
+	// Default is used when nothing matches, LocaleEN when empty.
+	Default Locale
+	// QueryParam and Cookie name the query parameter and cookie which
+	// override the Accept-Language header. Empty disables them.
+	QueryParam string
+	Cookie     string
+}
+
+// NewNegotiator creates a negotiator reading the "lang" query parameter
+// and cookie before the Accept-Language header. Supported locales which
+// are not registered yet are registered, so GetLocale recognises them.
+func NewNegotiator(supported ...Locale) *Negotiator {
+	for _, l := range supported {
+		if _, ok := LookupLocale(string(l)); !ok {
+			RegisterLocale(l)
+		}
+	}
+	return &Negotiator{
+		Supported:  supported,
+		Default:    LocaleEN,
+		QueryParam: "lang",
+		Cookie:     "lang",
+	}
+}
+
+// Negotiate returns the locale of r from the query parameter, the cookie
+// or the Accept-Language header, in that order.
+func (n *Negotiator) Negotiate(r *http.Request) Locale {
+	if n.QueryParam != "" {
+		if v := r.URL.Query().Get(n.QueryParam); v != "" {
+			if l, ok := Negotiate([]LanguageRange{{Tag: v, Q: 1}}, n.Supported); ok {
+				return l
+			}
+		}
+	}
+	if n.Cookie != "" {
+		if c, err := r.Cookie(n.Cookie); err == nil && c.Value != "" {
+			if l, ok := Negotiate([]LanguageRange{{Tag: c.Value, Q: 1}}, n.Supported); ok {
+				return l
+			}
+		}
+	}
+	if l, ok := Negotiate(ParseAcceptLanguage(r.Header.Get("Accept-Language")), n.Supported); ok {
+		return l
+	}
+
+	if n.Default == "" {
+		return LocaleEN
+	}
+	return n.Default
+}
+
+// Middleware stores the negotiated locale in the request context, so it can
+// be read with GetLocale and used by T.
+func (n *Negotiator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := n.Negotiate(r)
+		w.Header().Set("Content-Language", string(locale))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(SetLocale(r.Context(), string(locale))))
+	})
+}
+
+// Middleware is a shortcut of NewNegotiator(supported...).Middleware.
+func Middleware(supported ...Locale) func(http.Handler) http.Handler {
+	return NewNegotiator(supported...).Middleware
+}
@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	ranges := ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5, ja;q=0, xx;q=abc")
+	assert.Equal(t, []LanguageRange{
+		{Tag: "fr-CH", Q: 1},
+		{Tag: "fr", Q: 0.9},
+		{Tag: "en", Q: 0.8},
+		{Tag: "de", Q: 0.7},
+		{Tag: "*", Q: 0.5},
+	}, ranges)
+	assert.Empty(t, ParseAcceptLanguage(""))
+}
+
+func TestNegotiate(t *testing.T) {
+	supported := []Locale{LocaleEN, LocaleZH, LocaleZHTW}
+	cases := []struct {
+		header string
+		want   Locale
+		ok     bool
+	}{
+		{"zh-CN,zh;q=0.9,en;q=0.8", LocaleZH, true},
+		{"zh-Hant-TW", LocaleZHTW, true},
+		{"zh-HK", LocaleZHTW, true},
+		{"zh", LocaleZH, true},
+		{"en-GB,en;q=0.5", LocaleEN, true},
+		{"ja, en;q=0.1", LocaleEN, true},
+		{"ja;q=0.9, *;q=0.1", LocaleEN, true},
+		{"ja", "", false},
+		{"en;q=0.1, zh_TW", LocaleZHTW, true},
+	}
+	for _, c := range cases {
+		got, ok := Negotiate(ParseAcceptLanguage(c.header), supported)
+		assert.Equal(t, c.ok, ok, c.header)
+		assert.Equal(t, c.want, got, c.header)
+	}
+
+	got, ok := Negotiate(ParseAcceptLanguage("fr-CA"), []Locale{"fr-FR"})
+	assert.True(t, ok)
+	assert.Equal(t, Locale("fr-FR"), got)
+}
+
+func TestMiddleware(t *testing.T) {
+	var got Locale
+	h := NewNegotiator(LocaleEN, LocaleZH, "ja-JP").Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetLocale(r.Context())
+	}))
+
+	serve := func(target, header, cookie string) Locale {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: cookie})
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, string(got), rec.Header().Get("Content-Language"))
+		return got
+	}
+
+	assert.Equal(t, LocaleZH, serve("/", "zh-CN,en;q=0.5", ""))
+	assert.Equal(t, Locale("ja-JP"), serve("/", "ja", ""))
+	assert.Equal(t, LocaleEN, serve("/", "de", ""))
+	assert.Equal(t, LocaleEN, serve("/?lang=en", "zh-CN", "zh"))
+	assert.Equal(t, LocaleZH, serve("/", "en", "zh"))
+	assert.Equal(t, LocaleZH, serve("/?lang=xx", "", "zh-CN"))
+}
@@ -0,0 +1,208 @@
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PluralCategory is a CLDR plural category.
+type PluralCategory string
+
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralOperands are the CLDR plural operands of a number.
+// See: https://unicode.org/reports/tr35/tr35-numbers.html#Operands
+type PluralOperands struct {
+	N float64 // absolute value
+	I int64   // integer digits
+	V int     // number of visible fraction digits
+	F int64   // visible fraction digits
+}
+
+// PluralRule selects the plural category for the operands of a number.
+type PluralRule func(op PluralOperands) PluralCategory
+
+var (
+	pluralMu    sync.RWMutex
+	pluralRules = map[string]PluralRule{}
+)
+
+func init() {
+	for _, lang := range []string{"zh", "ja", "ko", "vi", "th", "id", "ms"} {
+		pluralRules[lang] = pluralRuleNone
+	}
+	for _, lang := range []string{"en", "de", "nl", "sv", "da", "no", "nb", "fi", "it", "es", "el", "tr", "hu"} {
+		pluralRules[lang] = pluralRuleOneOther
+	}
+	for _, lang := range []string{"fr", "pt"} {
+		pluralRules[lang] = pluralRuleFrench
+	}
+	for _, lang := range []string{"ru", "uk", "be"} {
+		pluralRules[lang] = pluralRuleEastSlavic
+	}
+	pluralRules["pl"] = pluralRulePolish
+	pluralRules["cs"] = pluralRuleCzech
+	pluralRules["sk"] = pluralRuleCzech
+	pluralRules["ar"] = pluralRuleArabic
+}
+
+// RegisterPluralRule registers the plural rule of a language, e.g. "en".
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralMu.Lock()
+	defer pluralMu.Unlock()
+	pluralRules[strings.ToLower(lang)] = rule
+}
+
+// PluralCategoryOf returns the plural category of n in locale. Languages
+// without a registered rule use the English rule.
+func PluralCategoryOf(locale Locale, n any) PluralCategory {
+	op, ok := NewPluralOperands(n)
+	if !ok {
+		return PluralOther
+	}
+
+	lang := strings.ToLower(string(locale))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	pluralMu.RLock()
+	rule, ok := pluralRules[lang]
+	pluralMu.RUnlock()
+	if !ok {
+		rule = pluralRuleOneOther
+	}
+	return rule(op)
+}
+
+// NewPluralOperands computes the plural operands of an integer, a float or
+// a decimal string such as "1.50".
+func NewPluralOperands(n any) (PluralOperands, bool) {
+	var s string
+	switch v := n.(type) {
+	case int:
+		s = strconv.FormatInt(int64(v), 10)
+	case int8, int16, int32, int64:
+		s = fmt.Sprintf("%d", v)
+	case uint, uint8, uint16, uint32, uint64:
+		s = fmt.Sprintf("%d", v)
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		s = v
+	default:
+		return PluralOperands{}, false
+	}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "-")
+	num, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(num, 0) || math.IsNaN(num) {
+		return PluralOperands{}, false
+	}
+
+	op := PluralOperands{N: num}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	op.I, _ = strconv.ParseInt(intPart, 10, 64)
+	op.V = len(fracPart)
+	if fracPart != "" {
+		op.F, _ = strconv.ParseInt(fracPart, 10, 64)
+	}
+	return op, true
+}
+
+func pluralRuleNone(PluralOperands) PluralCategory {
+	return PluralOther
+}
+
+func pluralRuleOneOther(op PluralOperands) PluralCategory {
+	if op.I == 1 && op.V == 0 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralRuleFrench(op PluralOperands) PluralCategory {
+	if op.I == 0 || op.I == 1 {
+		return PluralOne
+	}
+	if op.I != 0 && op.I%1000000 == 0 && op.V == 0 {
+		return PluralMany
+	}
+	return PluralOther
+}
+
+func pluralRuleEastSlavic(op PluralOperands) PluralCategory {
+	if op.V != 0 {
+		return PluralOther
+	}
+	mod10, mod100 := op.I%10, op.I%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+func pluralRulePolish(op PluralOperands) PluralCategory {
+	if op.V != 0 {
+		return PluralOther
+	}
+	mod10, mod100 := op.I%10, op.I%100
+	switch {
+	case op.I == 1:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+func pluralRuleCzech(op PluralOperands) PluralCategory {
+	switch {
+	case op.V != 0:
+		return PluralMany
+	case op.I == 1:
+		return PluralOne
+	case op.I >= 2 && op.I <= 4:
+		return PluralFew
+	default:
+		return PluralOther
+	}
+}
+
+func pluralRuleArabic(op PluralOperands) PluralCategory {
+	if op.V != 0 || op.N != math.Trunc(op.N) {
+		return PluralOther
+	}
+	mod100 := op.I % 100
+	switch {
+	case op.I == 0:
+		return PluralZero
+	case op.I == 1:
+		return PluralOne
+	case op.I == 2:
+		return PluralTwo
+	case mod100 >= 3 && mod100 <= 10:
+		return PluralFew
+	case mod100 >= 11:
+		return PluralMany
+	default:
+		return PluralOther
+	}
+}
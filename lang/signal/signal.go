@@ -1,13 +1,29 @@
 package signal
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 )
 
+var exitSignals = []os.Signal{syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM}
+
 func WaitExit() {
+	WaitExitCtx(context.Background())
+}
+
+// WaitExitCtx blocks until an exit signal is received or ctx is done,
+// and returns the received signal, or nil when ctx ended first.
+func WaitExitCtx(ctx context.Context) os.Signal {
 	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
-	<-signals
+	signal.Notify(signals, exitSignals...)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		return sig
+	case <-ctx.Done():
+		return nil
+	}
 }
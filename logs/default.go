@@ -145,6 +145,15 @@ func CtxTracef(ctx context.Context, format string, v ...interface{}) {
 type defaultLogger struct {
 	stdlog *log.Logger
 	level  Level
+	depth  int // frames of wrappers to skip when reporting the caller
+}
+
+// WithCallDepth returns a copy of the logger, sharing its output, that
+// reports the caller depth frames further up the stack.
+func (ll *defaultLogger) WithCallDepth(depth int) FullLogger {
+	c := *ll
+	c.depth += depth
+	return &c
 }
 
 func (ll *defaultLogger) SetOutput(w io.Writer) {
@@ -165,7 +174,7 @@ func (ll *defaultLogger) logf(lv Level, format *string, v ...interface{}) {
 	} else {
 		msg += fmt.Sprint(v...)
 	}
-	ll.stdlog.Output(4, msg)
+	ll.stdlog.Output(4+ll.depth, msg)
 	if lv == LevelFatal {
 		os.Exit(1)
 	}
@@ -185,7 +194,7 @@ func (ll *defaultLogger) logfCtx(ctx context.Context, lv Level, format *string,
 	} else {
 		msg += fmt.Sprint(v...)
 	}
-	ll.stdlog.Output(4, msg)
+	ll.stdlog.Output(4+ll.depth, msg)
 	if lv == LevelFatal {
 		os.Exit(1)
 	}
@@ -54,6 +54,14 @@ type FullLogger interface {
 	Control
 }
 
+// CallDepthLogger is a FullLogger reporting the file and line of its caller.
+// Wrappers adding a frame, such as ddm.NewRedactLogger, use WithCallDepth
+// to keep reporting the caller of the wrapper.
+type CallDepthLogger interface {
+	FullLogger
+	WithCallDepth(depth int) FullLogger
+}
+
 // Level defines the priority of a log message.
 // When a logs is configured with a level, any log message with a lower
 // log level (smaller by integer comparison) will not be output.
@@ -0,0 +1,328 @@
+// Package otp implements the one-time passwords of authenticator apps:
+// HOTP (RFC 4226), TOTP (RFC 6238) and otpauth:// provisioning URIs, plus
+// single-use recovery codes.
+//
+// Secrets are base32 strings, the form authenticator apps import. Store
+// them encrypted, e.g. with aes.Keyring, as they are needed in clear to
+// check codes.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/v-mars/library/utils"
+)
+
+var (
+	// ErrInvalidCode is returned for codes that do not match.
+	ErrInvalidCode = errors.New("otp: invalid code")
+
+	// ErrSecret is returned for secrets that are not base32.
+	ErrSecret = errors.New("otp: invalid secret")
+)
+
+const (
+	// DefaultSecretSize is the size of the generated secrets, 160 bits as
+	// recommended by RFC 4226.
+	DefaultSecretSize = 20
+
+	// DefaultDigits is the length of the codes.
+	DefaultDigits = 6
+
+	// DefaultPeriod is the lifetime of a TOTP code.
+	DefaultPeriod = 30 * time.Second
+
+	// DefaultSkew is the number of periods, or of HOTP counters, accepted
+	// around the expected one.
+	DefaultSkew = 1
+)
+
+// Algorithm is the HMAC hash. Most authenticator apps only support SHA1.
+type Algorithm int
+
+const (
+	SHA1 Algorithm = iota
+	SHA256
+	SHA512
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case SHA256:
+		return "SHA256"
+	case SHA512:
+		return "SHA512"
+	default:
+		return "SHA1"
+	}
+}
+
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random base32 secret of DefaultSecretSize bytes.
+func GenerateSecret() string {
+	return EncodeSecret(utils.Bytes(DefaultSecretSize))
+}
+
+// EncodeSecret encodes a raw secret in base32 without padding.
+func EncodeSecret(secret []byte) string {
+	return b32.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a base32 secret. Case, spaces and padding are
+// ignored, as users often type secrets by hand.
+func DecodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.Join(strings.Fields(secret), ""))
+	key, err := b32.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrSecret
+	}
+	return key, nil
+}
+
+type params struct {
+	digits    int
+	algorithm Algorithm
+	period    time.Duration
+	skew      int
+	issuer    string
+}
+
+// Option configures a TOTP or a HOTP.
+type Option func(*params)
+
+// WithDigits sets the length of the codes, from 6 to 10.
+func WithDigits(n int) Option {
+	return func(p *params) { p.digits = n }
+}
+
+// WithAlgorithm sets the HMAC hash.
+func WithAlgorithm(a Algorithm) Option {
+	return func(p *params) { p.algorithm = a }
+}
+
+// WithPeriod sets the lifetime of TOTP codes.
+func WithPeriod(d time.Duration) Option {
+	return func(p *params) { p.period = d }
+}
+
+// WithSkew sets how many periods before and after the current one TOTP
+// accepts, or how many counters after the expected one HOTP accepts.
+func WithSkew(n int) Option {
+	return func(p *params) { p.skew = n }
+}
+
+// WithIssuer sets the issuer shown by authenticator apps.
+func WithIssuer(issuer string) Option {
+	return func(p *params) { p.issuer = issuer }
+}
+
+func newParams(opts []Option) (params, error) {
+	p := params{digits: DefaultDigits, period: DefaultPeriod, skew: DefaultSkew}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	switch {
+	case p.digits < 6 || p.digits > 10:
+		return p, fmt.Errorf("otp: digits must be between 6 and 10, got %d", p.digits)
+	case p.period < time.Second:
+		return p, errors.New("otp: period must be at least one second")
+	case p.skew < 0:
+		return p, errors.New("otp: skew must not be negative")
+	case p.algorithm < SHA1 || p.algorithm > SHA512:
+		return p, errors.New("otp: unknown algorithm")
+	}
+	return p, nil
+}
+
+// code computes the HOTP value of counter, RFC 4226 section 5.3.
+func (p *params) code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(p.algorithm.hash(), key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := int64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+	mod := int64(1)
+	for i := 0; i < p.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", p.digits, value%mod)
+}
+
+// match compares code with the codes of counters first to last, all of
+// them in constant time, and returns the matching counter.
+func (p *params) match(key []byte, code string, first, last uint64) (uint64, bool) {
+	var matched uint64
+	found := 0
+	for c := first; ; c++ {
+		eq := subtle.ConstantTimeCompare([]byte(p.code(key, c)), []byte(code))
+		matched |= c & -uint64(eq&^found)
+		found |= eq
+		if c == last {
+			break
+		}
+	}
+	return matched, found == 1
+}
+
+func (p *params) uri(kind, secret, account string, extra url.Values) string {
+	label := account
+	if p.issuer != "" {
+		label = p.issuer + ":" + account
+	}
+	q := url.Values{"secret": {secret}}
+	if p.issuer != "" {
+		q.Set("issuer", p.issuer)
+	}
+	q.Set("algorithm", p.algorithm.String())
+	q.Set("digits", strconv.Itoa(p.digits))
+	for k, v := range extra {
+		q[k] = v
+	}
+	u := url.URL{Scheme: "otpauth", Host: kind, Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// TOTP generates and checks time-based codes.
+type TOTP struct {
+	params
+	now func() time.Time
+}
+
+// NewTOTP creates a TOTP, by default with 6 digit SHA1 codes every 30
+// seconds, the settings of all authenticator apps.
+func NewTOTP(opts ...Option) (*TOTP, error) {
+	p, err := newParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &TOTP{params: p, now: time.Now}, nil
+}
+
+// Step returns the time step of t, the counter of its code.
+func (o *TOTP) Step(t time.Time) int64 {
+	return t.Unix() / int64(o.period/time.Second)
+}
+
+// Generate returns the current code.
+func (o *TOTP) Generate(secret string) (string, error) {
+	return o.GenerateAt(secret, o.now())
+}
+
+// GenerateAt returns the code of t.
+func (o *TOTP) GenerateAt(secret string, t time.Time) (string, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return o.code(key, uint64(o.Step(t))), nil
+}
+
+// Validate checks code against the current time, see ValidateAt.
+func (o *TOTP) Validate(secret, code string) (int64, error) {
+	return o.ValidateAt(secret, code, o.now())
+}
+
+// ValidateAt checks code against the codes of the skew periods around t
+// and returns the step it matched. A code stays valid for its whole
+// window: store the step of the last accepted code and reject codes whose
+// step is not greater, so a code cannot be used twice.
+func (o *TOTP) ValidateAt(secret, code string, t time.Time) (int64, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	if len(code) != o.digits {
+		return 0, ErrInvalidCode
+	}
+	step := o.Step(t)
+	first := max(step-int64(o.skew), 0)
+	matched, ok := o.match(key, code, uint64(first), uint64(step+int64(o.skew)))
+	if !ok {
+		return 0, ErrInvalidCode
+	}
+	return int64(matched), nil
+}
+
+// URI returns the otpauth:// URI to show as a QR code to enroll secret.
+func (o *TOTP) URI(secret, account string) string {
+	return o.uri("totp", secret, account, url.Values{
+		"period": {strconv.Itoa(int(o.period / time.Second))},
+	})
+}
+
+// HOTP generates and checks counter-based codes.
+type HOTP struct {
+	params
+}
+
+// NewHOTP creates a HOTP, by default with 6 digit SHA1 codes. The skew is
+// the look-ahead window of Validate.
+func NewHOTP(opts ...Option) (*HOTP, error) {
+	p, err := newParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &HOTP{params: p}, nil
+}
+
+// Generate returns the code of counter.
+func (o *HOTP) Generate(secret string, counter uint64) (string, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return o.code(key, counter), nil
+}
+
+// Validate checks code against the codes of counter to counter + skew,
+// as the device may have generated codes that were never used. It returns
+// the counter to store for the next validation, one past the match.
+func (o *HOTP) Validate(secret, code string, counter uint64) (uint64, error) {
+	key, err := DecodeSecret(secret)
+	if err != nil {
+		return counter, err
+	}
+	if len(code) != o.digits || counter > ^uint64(0)-uint64(o.skew)-1 {
+		return counter, ErrInvalidCode
+	}
+	matched, ok := o.match(key, code, counter, counter+uint64(o.skew))
+	if !ok {
+		return counter, ErrInvalidCode
+	}
+	return matched + 1, nil
+}
+
+// URI returns the otpauth:// URI to enroll secret starting at counter.
+func (o *HOTP) URI(secret, account string, counter uint64) string {
+	return o.uri("hotp", secret, account, url.Values{
+		"counter": {strconv.FormatUint(counter, 10)},
+	})
+}
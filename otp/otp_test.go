@@ -0,0 +1,175 @@
+package otp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 4226 appendix D
+func TestHOTPVectors(t *testing.T) {
+	secret := EncodeSecret([]byte("12345678901234567890"))
+	want := []string{"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489"}
+
+	o, err := NewHOTP()
+	assert.NoError(t, err)
+	for i, code := range want {
+		got, err := o.Generate(secret, uint64(i))
+		assert.NoError(t, err)
+		assert.Equal(t, code, got, "counter %d", i)
+	}
+}
+
+// RFC 6238 appendix B
+func TestTOTPVectors(t *testing.T) {
+	secrets := map[Algorithm]string{
+		SHA1:   EncodeSecret([]byte("12345678901234567890")),
+		SHA256: EncodeSecret([]byte("12345678901234567890123456789012")),
+		SHA512: EncodeSecret([]byte("1234567890123456789012345678901234567890123456789012345678901234")),
+	}
+	for _, v := range []struct {
+		unix  int64
+		codes [3]string
+	}{
+		{59, [3]string{"94287082", "46119246", "90693936"}},
+		{1111111109, [3]string{"07081804", "68084774", "25091201"}},
+		{1111111111, [3]string{"14050471", "67062674", "99943326"}},
+		{1234567890, [3]string{"89005924", "91819424", "93441116"}},
+		{2000000000, [3]string{"69279037", "90698825", "38618901"}},
+		{20000000000, [3]string{"65353130", "77737706", "47863826"}},
+	} {
+		for i, alg := range []Algorithm{SHA1, SHA256, SHA512} {
+			o, err := NewTOTP(WithDigits(8), WithAlgorithm(alg))
+			assert.NoError(t, err)
+			code, err := o.GenerateAt(secrets[alg], time.Unix(v.unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, v.codes[i], code, "%s at %d", alg, v.unix)
+
+			step, err := o.ValidateAt(secrets[alg], v.codes[i], time.Unix(v.unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, v.unix/30, step)
+		}
+	}
+}
+
+func TestTOTPSkew(t *testing.T) {
+	secret := GenerateSecret()
+	assert.Len(t, secret, 32)
+
+	o, err := NewTOTP()
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	code, _ := o.GenerateAt(secret, now)
+
+	for offset, ok := range map[time.Duration]bool{
+		0:                 true,
+		-30 * time.Second: true,
+		30 * time.Second:  true,
+		-60 * time.Second: false,
+		90 * time.Second:  false,
+	} {
+		step, err := o.ValidateAt(secret, code, now.Add(offset))
+		if ok {
+			assert.NoError(t, err, offset)
+			assert.Equal(t, o.Step(now), step, offset)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidCode, offset)
+		}
+	}
+
+	strict, _ := NewTOTP(WithSkew(0))
+	_, err = strict.ValidateAt(secret, code, now.Add(30*time.Second))
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	_, err = o.ValidateAt(secret, "12345", now)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	_, err = o.ValidateAt("not base32!", code, now)
+	assert.ErrorIs(t, err, ErrSecret)
+
+	// the current time is used by default
+	code, err = o.Generate(secret)
+	assert.NoError(t, err)
+	_, err = o.Validate(secret, code)
+	assert.NoError(t, err)
+}
+
+func TestHOTPValidate(t *testing.T) {
+	secret := EncodeSecret([]byte("12345678901234567890"))
+	o, _ := NewHOTP(WithSkew(3))
+
+	next, err := o.Validate(secret, "969429", 1) // counter 3
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), next)
+
+	// used codes are rejected once the counter moved past them
+	next, err = o.Validate(secret, "969429", next)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	assert.Equal(t, uint64(4), next)
+
+	_, err = o.Validate(secret, "520489", 4) // counter 9, beyond the window
+	assert.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestOptions(t *testing.T) {
+	for _, opt := range []Option{WithDigits(5), WithDigits(11), WithPeriod(0), WithSkew(-1), WithAlgorithm(9)} {
+		_, err := NewTOTP(opt)
+		assert.Error(t, err)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	key, err := DecodeSecret("gezd gnbv gy3t qojq GEZDGNBVGY3TQOJQ====")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("12345678901234567890"), key)
+
+	for _, bad := range []string{"", "1", "abc!"} {
+		_, err = DecodeSecret(bad)
+		assert.ErrorIs(t, err, ErrSecret, bad)
+	}
+}
+
+func TestURI(t *testing.T) {
+	o, _ := NewTOTP(WithIssuer("ACME Co"))
+	uri := o.URI("JBSWY3DPEHPK3PXP", "alice@example.com")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/ACME%20Co:alice@example.com?"), uri)
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", q.Get("secret"))
+	assert.Equal(t, "ACME Co", q.Get("issuer"))
+	assert.Equal(t, "SHA1", q.Get("algorithm"))
+	assert.Equal(t, "6", q.Get("digits"))
+	assert.Equal(t, "30", q.Get("period"))
+
+	h, _ := NewHOTP(WithDigits(8), WithAlgorithm(SHA256))
+	u, _ = url.Parse(h.URI("JBSWY3DPEHPK3PXP", "bob", 7))
+	assert.Equal(t, "hotp", u.Host)
+	assert.Equal(t, "/bob", u.Path)
+	assert.Equal(t, "7", u.Query().Get("counter"))
+	assert.Equal(t, "SHA256", u.Query().Get("algorithm"))
+	assert.Equal(t, "8", u.Query().Get("digits"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	rc := GenerateRecoveryCodes(3)
+	assert.Len(t, rc.Codes, 3)
+	assert.Len(t, rc.Hashes, 3)
+	for i, code := range rc.Codes {
+		assert.Len(t, code, 11)
+		assert.NotContains(t, rc.Hashes[i], strings.ReplaceAll(code, "-", ""))
+	}
+
+	assert.Equal(t, 1, VerifyRecoveryCode(rc.Codes[1], rc.Hashes))
+	assert.Equal(t, 2, VerifyRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(rc.Codes[2], "-", ""))+" ", rc.Hashes))
+	assert.Equal(t, -1, VerifyRecoveryCode("aaaaa-aaaaa", rc.Hashes))
+	assert.Equal(t, -1, VerifyRecoveryCode("short", rc.Hashes))
+
+	// a used code is removed
+	rc.Hashes[1] = ""
+	assert.Equal(t, -1, VerifyRecoveryCode(rc.Codes[1], rc.Hashes))
+}
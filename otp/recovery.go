@@ -0,0 +1,65 @@
+package otp
+
+import (
+	"strings"
+
+	"github.com/v-mars/library/utils"
+)
+
+const (
+	// DefaultRecoveryCodes is the number of codes GenerateRecoveryCodes
+	// returns by default.
+	DefaultRecoveryCodes = 10
+
+	// recoveryChars are lowercase letters and digits without look-alikes,
+	// 10 of them give about 50 bits
+	recoveryChars = "23456789abcdefghijkmnpqrstuvwxyz"
+	recoveryLen   = 10
+)
+
+// RecoveryCodes are single-use codes to sign in without the second factor.
+// Show Codes to the user once and store only Hashes.
+type RecoveryCodes struct {
+	Codes  []string
+	Hashes []string
+}
+
+// GenerateRecoveryCodes generates n codes, DefaultRecoveryCodes when n is
+// not positive, formatted as "xxxxx-xxxxx", and their bcrypt hashes.
+func GenerateRecoveryCodes(n int) RecoveryCodes {
+	if n <= 0 {
+		n = DefaultRecoveryCodes
+	}
+	rc := RecoveryCodes{Codes: make([]string, n), Hashes: make([]string, n)}
+	for i := range n {
+		code := utils.RandStr(recoveryLen, recoveryChars)
+		rc.Codes[i] = code[:recoveryLen/2] + "-" + code[recoveryLen/2:]
+		rc.Hashes[i] = utils.HashAndSalt([]byte(code))
+	}
+	return rc
+}
+
+// VerifyRecoveryCode returns the index of the hash matching code, or -1.
+// Remove the matched hash so the code cannot be used again. Case, spaces
+// and dashes in code are ignored.
+func VerifyRecoveryCode(code string, hashes []string) int {
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryLen {
+		return -1
+	}
+	for i, h := range hashes {
+		if h != "" && utils.ValidateSaltPasswords(h, []byte(normalized)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
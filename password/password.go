@@ -0,0 +1,348 @@
+// Package password hashes and verifies passwords with argon2id, scrypt or
+// bcrypt, in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//	$scrypt$ln=15,r=8,p=1$<salt>$<hash>
+//	$2a$10$<bcrypt salt and hash>
+//
+// Verify reports when a hash uses another algorithm or weaker parameters
+// than the Hasher, so that logins can upgrade the stored hashes.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Algorithm is a password hashing algorithm.
+type Algorithm string
+
+const (
+	Argon2id Algorithm = "argon2id"
+	Scrypt   Algorithm = "scrypt"
+	Bcrypt   Algorithm = "bcrypt"
+)
+
+// ErrInvalidHash is returned for hashes that are not in a supported format.
+var ErrInvalidHash = errors.New("password: invalid hash")
+
+// Argon2Params are the parameters of argon2id.
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// ScryptParams are the parameters of scrypt.
+type ScryptParams struct {
+	LogN    uint8 // N = 2^LogN
+	R       int
+	P       int
+	KeyLen  int
+	SaltLen int
+}
+
+var (
+	// DefaultArgon2Params is the second recommended option of RFC 9106.
+	DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 << 10, Threads: 4, KeyLen: 32, SaltLen: 16}
+
+	// DefaultScryptParams is N=2^15, r=8, p=1 as recommended by OWASP.
+	DefaultScryptParams = ScryptParams{LogN: 15, R: 8, P: 1, KeyLen: 32, SaltLen: 16}
+
+	// DefaultBcryptCost is the bcrypt cost of the Hasher, above
+	// bcrypt.DefaultCost used by utils.HashAndSalt.
+	DefaultBcryptCost = 12
+)
+
+// Hasher hashes passwords with one algorithm and verifies the hashes of
+// all of them.
+type Hasher struct {
+	algorithm  Algorithm
+	argon2     Argon2Params
+	scrypt     ScryptParams
+	bcryptCost int
+	policy     *Policy
+}
+
+// Option configures a Hasher.
+type Option func(*Hasher)
+
+// WithAlgorithm sets the algorithm of new hashes, Argon2id by default.
+func WithAlgorithm(alg Algorithm) Option {
+	return func(h *Hasher) { h.algorithm = alg }
+}
+
+// WithArgon2Params sets the argon2id parameters.
+func WithArgon2Params(p Argon2Params) Option {
+	return func(h *Hasher) { h.argon2 = p }
+}
+
+// WithScryptParams sets the scrypt parameters.
+func WithScryptParams(p ScryptParams) Option {
+	return func(h *Hasher) { h.scrypt = p }
+}
+
+// WithBcryptCost sets the bcrypt cost.
+func WithBcryptCost(cost int) Option {
+	return func(h *Hasher) { h.bcryptCost = cost }
+}
+
+// WithPolicy makes Hash reject the passwords that fail policy.
+func WithPolicy(policy Policy) Option {
+	return func(h *Hasher) { h.policy = &policy }
+}
+
+// NewHasher creates a Hasher.
+func NewHasher(opts ...Option) (*Hasher, error) {
+	h := &Hasher{
+		algorithm:  Argon2id,
+		argon2:     DefaultArgon2Params,
+		scrypt:     DefaultScryptParams,
+		bcryptCost: DefaultBcryptCost,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	switch h.algorithm {
+	case Argon2id:
+		if h.argon2.Time == 0 || h.argon2.Memory < 8*uint32(h.argon2.Threads) || h.argon2.Threads == 0 ||
+			h.argon2.KeyLen < 16 || h.argon2.SaltLen < 8 {
+			return nil, fmt.Errorf("password: invalid argon2 params %+v", h.argon2)
+		}
+	case Scrypt:
+		if h.scrypt.LogN == 0 || h.scrypt.LogN > 30 || h.scrypt.R <= 0 || h.scrypt.P <= 0 ||
+			h.scrypt.KeyLen < 16 || h.scrypt.SaltLen < 8 {
+			return nil, fmt.Errorf("password: invalid scrypt params %+v", h.scrypt)
+		}
+	case Bcrypt:
+		if h.bcryptCost < bcrypt.MinCost || h.bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("password: invalid bcrypt cost %d", h.bcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("password: unknown algorithm %q", h.algorithm)
+	}
+	return h, nil
+}
+
+var b64 = base64.RawStdEncoding
+
+// Hash checks password against the policy of h, then hashes it.
+func (h *Hasher) Hash(password string, userInputs ...string) (string, error) {
+	if h.policy != nil {
+		if err := h.policy.Check(password, userInputs...); err != nil {
+			return "", err
+		}
+	}
+
+	switch h.algorithm {
+	case Argon2id:
+		p := h.argon2
+		salt, err := randomSalt(int(p.SaltLen))
+		if err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, p.Memory, p.Time, p.Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+	case Scrypt:
+		p := h.scrypt
+		salt, err := randomSalt(p.SaltLen)
+		if err != nil {
+			return "", err
+		}
+		key, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, p.KeyLen)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s",
+			p.LogN, p.R, p.P, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+	default:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("password: %w", err)
+		}
+		return string(hash), nil
+	}
+}
+
+func randomSalt(n int) ([]byte, error) {
+	salt := make([]byte, n)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("password: %w", err)
+	}
+	return salt, nil
+}
+
+// Verify reports whether password matches encoded, which may use any of
+// the algorithms. needsRehash is true for a match whose algorithm or
+// parameters differ from those of h: hash the password again and store it.
+// err is only set for malformed hashes.
+func (h *Hasher) Verify(password, encoded string) (match, needsRehash bool, err error) {
+	alg, err := Identify(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	switch alg {
+	case Argon2id:
+		p, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		match = subtle.ConstantTimeCompare(got, key) == 1
+		needsRehash = h.algorithm != Argon2id || p != h.argon2
+	case Scrypt:
+		p, salt, key, err := decodeScrypt(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		got, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, len(key))
+		if err != nil {
+			return false, false, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		match = subtle.ConstantTimeCompare(got, key) == 1
+		needsRehash = h.algorithm != Scrypt || p != h.scrypt
+	case Bcrypt:
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if err != nil && !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		match = err == nil
+		needsRehash = h.algorithm != Bcrypt || cost != h.bcryptCost
+	}
+
+	if !match {
+		return false, false, nil
+	}
+	return true, needsRehash, nil
+}
+
+// VerifyAndUpgrade is Verify for logins: when password matches a hash
+// that needs a rehash, it returns the new hash to store, else "".
+func (h *Hasher) VerifyAndUpgrade(password, encoded string) (match bool, newHash string, err error) {
+	match, needsRehash, err := h.Verify(password, encoded)
+	if err != nil || !match || !needsRehash {
+		return match, "", err
+	}
+
+	// the policy may have changed since, an upgrade must not lock users out
+	upgrade := *h
+	upgrade.policy = nil
+	if newHash, err = upgrade.Hash(password); err != nil {
+		return true, "", err
+	}
+	return true, newHash, nil
+}
+
+// Identify returns the algorithm of an encoded hash.
+func Identify(encoded string) (Algorithm, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return Argon2id, nil
+	case strings.HasPrefix(encoded, "$scrypt$"):
+		return Scrypt, nil
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return Bcrypt, nil
+	}
+	return "", ErrInvalidHash
+}
+
+// phcParams parses "k=v,k=v" into the values of keys, in order.
+func phcParams(s string, keys ...string) ([]uint64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != len(keys) {
+		return nil, ErrInvalidHash
+	}
+	values := make([]uint64, len(keys))
+	for i, part := range parts {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || k != keys[i] {
+			return nil, ErrInvalidHash
+		}
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		values[i] = n
+	}
+	return values, nil
+}
+
+func decodeSaltAndKey(salt, key string) ([]byte, []byte, error) {
+	s, err := b64.DecodeString(salt)
+	if err != nil {
+		return nil, nil, ErrInvalidHash
+	}
+	k, err := b64.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, nil, ErrInvalidHash
+	}
+	return s, k, nil
+}
+
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[2] != "v="+strconv.Itoa(argon2.Version) {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	v, err := phcParams(parts[3], "m", "t", "p")
+	if err != nil || v[2] == 0 || v[2] > 255 || v[1] == 0 {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	salt, key, err := decodeSaltAndKey(parts[4], parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, err
+	}
+
+	p := Argon2Params{
+		Memory:  uint32(v[0]),
+		Time:    uint32(v[1]),
+		Threads: uint8(v[2]),
+		KeyLen:  uint32(len(key)),
+		SaltLen: uint32(len(salt)),
+	}
+	return p, salt, key, nil
+}
+
+func decodeScrypt(encoded string) (ScryptParams, []byte, []byte, error) {
+	// "", "scrypt", "ln=...,r=...,p=...", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return ScryptParams{}, nil, nil, ErrInvalidHash
+	}
+	v, err := phcParams(parts[2], "ln", "r", "p")
+	if err != nil || v[0] == 0 || v[0] > 30 {
+		return ScryptParams{}, nil, nil, ErrInvalidHash
+	}
+	salt, key, err := decodeSaltAndKey(parts[3], parts[4])
+	if err != nil {
+		return ScryptParams{}, nil, nil, err
+	}
+
+	p := ScryptParams{
+		LogN:    uint8(v[0]),
+		R:       int(v[1]),
+		P:       int(v[2]),
+		KeyLen:  len(key),
+		SaltLen: len(salt),
+	}
+	return p, salt, key, nil
+}
@@ -0,0 +1,166 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/v-mars/library/utils"
+)
+
+// cheap parameters to keep the tests fast
+var (
+	testArgon2 = WithArgon2Params(Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+	testScrypt = WithScryptParams(ScryptParams{LogN: 4, R: 8, P: 1, KeyLen: 32, SaltLen: 16})
+	testBcrypt = WithBcryptCost(bcrypt.MinCost)
+)
+
+func TestHashVerify(t *testing.T) {
+	for _, alg := range []Algorithm{Argon2id, Scrypt, Bcrypt} {
+		h, err := NewHasher(WithAlgorithm(alg), testArgon2, testScrypt, testBcrypt)
+		assert.NoError(t, err)
+
+		hash, err := h.Hash("correct horse")
+		assert.NoError(t, err)
+		got, _ := Identify(hash)
+		assert.Equal(t, alg, got)
+
+		other, _ := h.Hash("correct horse")
+		assert.NotEqual(t, hash, other, "salted")
+
+		match, rehash, err := h.Verify("correct horse", hash)
+		assert.NoError(t, err)
+		assert.True(t, match, alg)
+		assert.False(t, rehash, alg)
+
+		match, rehash, err = h.Verify("wrong horse", hash)
+		assert.NoError(t, err)
+		assert.False(t, match, alg)
+		assert.False(t, rehash, alg)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	h, _ := NewHasher(testArgon2)
+	hash, _ := h.Hash("pw")
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hash)
+
+	h, _ = NewHasher(WithAlgorithm(Scrypt), testScrypt)
+	hash, _ = h.Hash("pw")
+	assert.Regexp(t, `^\$scrypt\$ln=4,r=8,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hash)
+
+	// hashes of utils.HashAndSalt
+	h, _ = NewHasher(WithAlgorithm(Bcrypt))
+	match, rehash, err := h.Verify("admin", utils.HashAndSalt([]byte("admin")))
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, rehash, "cost 10 is below the default 12")
+
+	for _, bad := range []string{
+		"", "plain", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$t=1,m=1024,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1024,t=1,p=0$c2FsdA$a2V5",
+		"$scrypt$ln=99,r=8,p=1$c2FsdA$a2V5", "$scrypt$ln=4,r=8,p=1$c2FsdA$", "$2a$10$short",
+	} {
+		_, _, err = h.Verify("pw", bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weak, _ := NewHasher(WithArgon2Params(Argon2Params{Time: 1, Memory: 512, Threads: 1, KeyLen: 32, SaltLen: 16}))
+	old, _ := weak.Hash("s3cret")
+
+	h, _ := NewHasher(testArgon2)
+	match, rehash, err := h.Verify("s3cret", old)
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, rehash)
+
+	bcryptHasher, _ := NewHasher(WithAlgorithm(Bcrypt), testBcrypt)
+	legacy, _ := bcryptHasher.Hash("s3cret")
+
+	match, upgraded, err := h.VerifyAndUpgrade("s3cret", legacy)
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, strings.HasPrefix(upgraded, "$argon2id$"))
+
+	match, rehash, _ = h.Verify("s3cret", upgraded)
+	assert.True(t, match)
+	assert.False(t, rehash)
+
+	match, upgraded, err = h.VerifyAndUpgrade("s3cret", upgraded)
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.Empty(t, upgraded)
+
+	match, upgraded, _ = h.VerifyAndUpgrade("wrong", legacy)
+	assert.False(t, match)
+	assert.Empty(t, upgraded)
+}
+
+func TestNewHasherInvalid(t *testing.T) {
+	for _, opt := range []Option{
+		WithAlgorithm("md5"),
+		WithArgon2Params(Argon2Params{}),
+		func(h *Hasher) { h.algorithm = Scrypt; h.scrypt = ScryptParams{LogN: 40} },
+		func(h *Hasher) { h.algorithm = Bcrypt; h.bcryptCost = 99 },
+	} {
+		_, err := NewHasher(opt)
+		assert.Error(t, err)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	p := DefaultPolicy
+	assert.NoError(t, p.Check("Tr0ub4dor&3"))
+	assert.NoError(t, p.Check("correct Horse battery"))
+
+	for pw, want := range map[string]string{
+		"Ab1!":             "shorter than 8 characters",
+		"abcdefgh":         "fewer than 3 kinds of characters",
+		"Password123":      "too common",
+		"Aaaaaaaa1":        "too predictable",
+		"Abcdefgh1234":     "too predictable",
+		"alice-Rocks-2024": "contains personal information",
+	} {
+		err := p.Check(pw, "Alice@example.com")
+		assert.ErrorIs(t, err, ErrWeakPassword, pw)
+		var pe *PolicyError
+		if assert.ErrorAs(t, err, &pe) {
+			assert.Contains(t, pe.Violations, want, pw)
+		}
+	}
+
+	strict := Policy{Require: utils.ClassUpper | utils.ClassSymbol}
+	err := strict.Check("lowercase")
+	assert.EqualError(t, err, "password: no uppercase letter, no symbol")
+
+	h, _ := NewHasher(testArgon2, WithPolicy(DefaultPolicy))
+	_, err = h.Hash("123456")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	_, err = h.Hash("bob-Secret-99", "bob")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	_, err = h.Hash("Tr0ub4dor&3", "bob")
+	assert.NoError(t, err)
+
+	assert.NoError(t, Policy{}.Check(""))
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Equal(t, 0.0, Entropy(""))
+	assert.InDelta(t, Entropy("ab"), Entropy("abcdefgh"), 1e-9)
+	assert.InDelta(t, Entropy("ab"), Entropy("aaaaaaab"), 1e-9)
+	assert.Greater(t, Entropy("Tr0ub4dor&3"), 60.0)
+	assert.Equal(t, utils.ClassLower|utils.ClassSymbol, Classes("密码abc"))
+}
+
+func BenchmarkArgon2Default(b *testing.B) {
+	h, _ := NewHasher()
+	hash, _ := h.Hash("benchmark")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = h.Verify("benchmark", hash)
+	}
+}
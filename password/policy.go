@@ -0,0 +1,191 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/v-mars/library/utils"
+)
+
+// ErrWeakPassword is matched by the errors of Policy.Check.
+var ErrWeakPassword = errors.New("password: too weak")
+
+// PolicyError lists the rules a password breaks.
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password: " + strings.Join(e.Violations, ", ")
+}
+
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// Policy is a password strength policy. The zero value accepts anything.
+type Policy struct {
+	// MinLength and MaxLength count characters; MaxLength 0 means no limit.
+	MinLength int
+	MaxLength int
+	// Require lists the character classes that must appear.
+	Require utils.CharClass
+	// MinClasses is the number of distinct classes that must appear.
+	MinClasses int
+	// MinEntropy is the minimum estimated entropy in bits, see Entropy.
+	MinEntropy float64
+	// Blocklist holds common passwords, compared case-insensitively.
+	Blocklist []string
+	// RejectUserInputs rejects passwords containing the user inputs given to
+	// Check, such as the user name or the email.
+	RejectUserInputs bool
+}
+
+// CommonPasswords are among the most used passwords of public breaches.
+var CommonPasswords = []string{
+	"123456", "123456789", "12345678", "1234567890", "12345", "1234567", "111111", "000000",
+	"123123", "654321", "666666", "888888", "password", "password1", "password123", "passw0rd",
+	"qwerty", "qwerty123", "qwertyuiop", "1q2w3e4r", "1qaz2wsx", "abc123", "a123456", "admin",
+	"admin123", "root", "welcome", "letmein", "iloveyou", "monkey", "dragon", "football",
+	"sunshine", "princess", "woaini", "woaini1314", "5201314", "aa123456", "zxcvbnm", "asdfghjkl",
+}
+
+// DefaultPolicy requires 8 characters of at least 3 classes, about 40 bits
+// of estimated entropy, and rejects common passwords and user inputs.
+var DefaultPolicy = Policy{
+	MinLength:        8,
+	MaxLength:        128,
+	MinClasses:       3,
+	MinEntropy:       40,
+	Blocklist:        CommonPasswords,
+	RejectUserInputs: true,
+}
+
+// Check returns a *PolicyError, matching ErrWeakPassword, listing the rules
+// password breaks.
+func (p Policy) Check(password string, userInputs ...string) error {
+	var violations []string
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength {
+		violations = append(violations, fmt.Sprintf("shorter than %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("longer than %d characters", p.MaxLength))
+	}
+
+	classes := Classes(password)
+	for _, c := range []struct {
+		class utils.CharClass
+		name  string
+	}{
+		{utils.ClassLower, "lowercase letter"},
+		{utils.ClassUpper, "uppercase letter"},
+		{utils.ClassDigit, "digit"},
+		{utils.ClassSymbol, "symbol"},
+	} {
+		if p.Require&c.class != 0 && classes&c.class == 0 {
+			violations = append(violations, "no "+c.name)
+		}
+	}
+	if count := classCount(classes); count < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("fewer than %d kinds of characters", p.MinClasses))
+	}
+	if p.MinEntropy > 0 && Entropy(password) < p.MinEntropy {
+		violations = append(violations, "too predictable")
+	}
+
+	lower := strings.ToLower(password)
+	for _, common := range p.Blocklist {
+		if lower == strings.ToLower(common) {
+			violations = append(violations, "too common")
+			break
+		}
+	}
+	if p.RejectUserInputs {
+		for _, input := range userInputs {
+			input = strings.ToLower(input)
+			if name, _, ok := strings.Cut(input, "@"); ok {
+				input = name
+			}
+			if utf8.RuneCountInString(input) >= 3 && strings.Contains(lower, input) {
+				violations = append(violations, "contains personal information")
+				break
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Classes returns the character classes found in password. Letters of
+// other scripts, such as Chinese, count as symbols.
+func Classes(password string) utils.CharClass {
+	var classes utils.CharClass
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			classes |= utils.ClassLower
+		case r >= 'A' && r <= 'Z':
+			classes |= utils.ClassUpper
+		case r >= '0' && r <= '9':
+			classes |= utils.ClassDigit
+		case !unicode.IsSpace(r) || r == ' ':
+			classes |= utils.ClassSymbol
+		}
+	}
+	return classes
+}
+
+func classCount(c utils.CharClass) int {
+	n := 0
+	for ; c != 0; c &= c - 1 {
+		n++
+	}
+	return n
+}
+
+// Entropy estimates the entropy of password in bits as its length times
+// log2 of the size of the classes it uses, discounting repeated characters
+// and runs such as "aaaa" or "1234". It is an upper bound for human chosen
+// passwords; use it to reject obviously weak ones, not to rate good ones.
+func Entropy(password string) float64 {
+	classes := Classes(password)
+	pool := 0
+	if classes&utils.ClassLower != 0 {
+		pool += 26
+	}
+	if classes&utils.ClassUpper != 0 {
+		pool += 26
+	}
+	if classes&utils.ClassDigit != 0 {
+		pool += 10
+	}
+	if classes&utils.ClassSymbol != 0 {
+		pool += 33
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	// count the characters that do not follow the previous one or continue
+	// a sequence, e.g. "aaab" and "abcd" count as 2 characters
+	effective := 0
+	var prev, delta rune
+	for i, r := range []rune(password) {
+		d := r - prev
+		repeat := i > 0 && d == 0
+		run := i > 1 && d == delta && (d == 1 || d == -1)
+		if !repeat && !run {
+			effective++
+		}
+		prev, delta = r, d
+	}
+	return float64(effective) * math.Log2(float64(pool))
+}
@@ -0,0 +1,74 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/v-mars/library/aes"
+)
+
+// envelopeVersion is the version byte of the hybrid envelope:
+//
+//	version (1) | RSA-OAEP-SHA256 encrypted AES-256 key (modulus size) | aes.GCM envelope
+//
+// The encrypted key is authenticated as associated data of the AES-GCM
+// envelope.
+const envelopeVersion byte = 1
+
+// ErrEnvelope is returned for data that is not a well formed envelope.
+var ErrEnvelope = errors.New("rsa: malformed envelope")
+
+func (pub *rsaPub) Seal(plaintext []byte) ([]byte, error) {
+	publicKey, err := pub.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err = rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, dataKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := aes.NewGCM("", dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := g.Seal(plaintext, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 1+len(wrapped)+len(sealed))
+	out = append(out, envelopeVersion)
+	out = append(out, wrapped...)
+	return append(out, sealed...), nil
+}
+
+func (pri *rsaPri) Open(envelope []byte) ([]byte, error) {
+	privateKey, err := pri.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	size := privateKey.Size()
+	if len(envelope) < 1+size || envelope[0] != envelopeVersion {
+		return nil, ErrEnvelope
+	}
+	wrapped := envelope[1 : 1+size]
+	dataKey, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, wrapped, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := aes.NewGCM("", dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return g.Open(envelope[1+size:], wrapped)
+}
@@ -0,0 +1,154 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MinKeyBits is the smallest key size GenerateKey accepts.
+const MinKeyBits = 2048
+
+// ErrKeyFormat is returned for keys that are neither PEM nor base64 DER in
+// a supported format.
+var ErrKeyFormat = errors.New("rsa: unsupported key format")
+
+// KeyFormat is the encoding of a key in PEM.
+type KeyFormat int
+
+const (
+	// PKCS1 is "RSA PRIVATE KEY" or "RSA PUBLIC KEY".
+	PKCS1 KeyFormat = iota + 1
+	// PKCS8 is "PRIVATE KEY", the format of openssl genpkey and Java.
+	PKCS8
+	// PKIX is "PUBLIC KEY", the format of openssl rsa -pubout and JSEncrypt.
+	PKIX
+)
+
+// GenerateKey generates a private key of bits, at least MinKeyBits.
+func GenerateKey(bits int) (*rsa.PrivateKey, error) {
+	if bits < MinKeyBits {
+		return nil, fmt.Errorf("rsa: key size %d is less than %d bits", bits, MinKeyBits)
+	}
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+// GenerateKeyPair generates a key pair in PEM, the private key in PKCS#8
+// and the public key in PKIX, which NewPrivate and NewPublic accept.
+func GenerateKeyPair(bits int) (privatePEM, publicPEM string, err error) {
+	key, err := GenerateKey(bits)
+	if err != nil {
+		return "", "", err
+	}
+	if privatePEM, err = MarshalPrivateKey(key, PKCS8); err != nil {
+		return "", "", err
+	}
+	if publicPEM, err = MarshalPublicKey(&key.PublicKey, PKIX); err != nil {
+		return "", "", err
+	}
+	return privatePEM, publicPEM, nil
+}
+
+// MarshalPrivateKey encodes key in PEM as PKCS1 or PKCS8.
+func MarshalPrivateKey(key *rsa.PrivateKey, format KeyFormat) (string, error) {
+	var block pem.Block
+	switch format {
+	case PKCS1:
+		block = pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case PKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		block = pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		return "", ErrKeyFormat
+	}
+	return string(pem.EncodeToMemory(&block)), nil
+}
+
+// MarshalPublicKey encodes key in PEM as PKCS1 or PKIX.
+func MarshalPublicKey(key *rsa.PublicKey, format KeyFormat) (string, error) {
+	var block pem.Block
+	switch format {
+	case PKCS1:
+		block = pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(key)}
+	case PKIX:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return "", err
+		}
+		block = pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		return "", ErrKeyFormat
+	}
+	return string(pem.EncodeToMemory(&block)), nil
+}
+
+// keyDER returns the type and DER of a PEM key, or of a base64 DER key
+// without PEM armor as pasted from many consoles.
+func keyDER(key string) (string, []byte, error) {
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		return block.Type, block.Bytes, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(key), ""))
+	if err != nil {
+		return "", nil, ErrKeyFormat
+	}
+	return "", der, nil
+}
+
+// ParsePrivateKey parses a PKCS#1 or PKCS#8 private key, in PEM or base64
+// DER, whatever the PEM type says.
+func ParsePrivateKey(key string) (*rsa.PrivateKey, error) {
+	_, der, err := keyDER(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if k, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, ErrKeyFormat
+	}
+	rsaKey, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an RSA key", ErrKeyFormat, k)
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey parses a PKIX or PKCS#1 public key, or the key of an X.509
+// certificate, in PEM or base64 DER.
+func ParsePublicKey(key string) (*rsa.PublicKey, error) {
+	typ, der, err := keyDER(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var k any
+	if typ == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		k = cert.PublicKey
+	} else if k, err = x509.ParsePKIXPublicKey(der); err != nil {
+		if k, err = x509.ParsePKCS1PublicKey(der); err != nil {
+			return nil, ErrKeyFormat
+		}
+	}
+
+	rsaKey, ok := k.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not an RSA key", ErrKeyFormat, k)
+	}
+	return rsaKey, nil
+}
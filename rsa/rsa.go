@@ -3,9 +3,10 @@ package rsa
 import (
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/x509"
+	"crypto/sha256"
 	"encoding/base64"
-	"encoding/pem"
+	"errors"
+	"sync"
 )
 
 var _ Public = (*rsaPub)(nil)
@@ -13,77 +14,214 @@ var _ Private = (*rsaPri)(nil)
 
 type Public interface {
 	i()
-	// Encrypt 加密
+	// Encrypt 加密，PKCS#1 v1.5，超长明文分段加密
 	Encrypt(encryptStr string) (string, error)
+
+	// EncryptOAEP 使用 OAEP-SHA256 加密，超长明文分段加密
+	EncryptOAEP(plaintext, label []byte) ([]byte, error)
+
+	// Seal 使用 RSA+AES-GCM 混合加密任意长度的明文
+	Seal(plaintext []byte) ([]byte, error)
+
+	// Verify 验证签名
+	Verify(data, sig []byte, scheme Scheme) error
+
+	// Key 返回解析后的公钥
+	Key() (*rsa.PublicKey, error)
 }
 
 type Private interface {
 	i()
 	// Decrypt 解密
 	Decrypt(decryptStr string) (string, error)
+
+	// DecryptOAEP 解密 EncryptOAEP 的密文
+	DecryptOAEP(ciphertext, label []byte) ([]byte, error)
+
+	// Open 解密 Seal 的密文
+	Open(envelope []byte) ([]byte, error)
+
+	// Sign 签名
+	Sign(data []byte, scheme Scheme) ([]byte, error)
+
+	// Public 返回对应的公钥
+	Public() Public
+
+	// Key 返回解析后的私钥
+	Key() (*rsa.PrivateKey, error)
 }
 
+// rsaPub and rsaPri parse their key once, on first use, and keep the
+// result and the error.
 type rsaPub struct {
 	PublicKey string
+	once      sync.Once
+	key       *rsa.PublicKey
+	err       error
 }
 
 type rsaPri struct {
 	PrivateKey string
+	once       sync.Once
+	key        *rsa.PrivateKey
+	err        error
 }
 
+// NewPublic accepts a PKIX or PKCS#1 public key or a certificate, see
+// ParsePublicKey. The key is parsed once on first use.
 func NewPublic(publicKey string) Public {
 	return &rsaPub{
 		PublicKey: publicKey,
 	}
 }
 
+// NewPrivate accepts a PKCS#1 or PKCS#8 private key, see ParsePrivateKey.
+// The key is parsed once on first use.
 func NewPrivate(privateKey string) Private {
 	return &rsaPri{
 		PrivateKey: privateKey,
 	}
 }
 
+// NewPublicKey wraps a parsed public key.
+func NewPublicKey(key *rsa.PublicKey) Public {
+	return &rsaPub{key: key}
+}
+
+// NewPrivateKey wraps a parsed private key.
+func NewPrivateKey(key *rsa.PrivateKey) Private {
+	return &rsaPri{key: key}
+}
+
 func (pub *rsaPub) i() {}
 
-func (pub *rsaPub) Encrypt(encryptStr string) (string, error) {
-	// pem 解码
-	block, _ := pem.Decode([]byte(pub.PublicKey))
+func (pub *rsaPub) Key() (*rsa.PublicKey, error) {
+	pub.once.Do(func() {
+		if pub.key == nil && pub.err == nil {
+			pub.key, pub.err = ParsePublicKey(pub.PublicKey)
+		}
+	})
+	return pub.key, pub.err
+}
 
-	// x509 解码
-	publicKeyInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
+func (pub *rsaPub) Encrypt(encryptStr string) (string, error) {
+	publicKey, err := pub.Key()
 	if err != nil {
 		return "", err
 	}
 
-	// 类型断言
-	publicKey := publicKeyInterface.(*rsa.PublicKey)
-
-	//对明文进行加密
-	encryptedStr, err := rsa.EncryptPKCS1v15(rand.Reader, publicKey, []byte(encryptStr))
+	//对明文进行分段加密
+	encrypted, err := chunked([]byte(encryptStr), publicKey.Size()-11, func(chunk []byte) ([]byte, error) {
+		return rsa.EncryptPKCS1v15(rand.Reader, publicKey, chunk)
+	})
 	if err != nil {
 		return "", err
 	}
 
 	//返回密文
-	return base64.URLEncoding.EncodeToString(encryptedStr), nil
+	return base64.URLEncoding.EncodeToString(encrypted), nil
+}
+
+func (pub *rsaPub) EncryptOAEP(plaintext, label []byte) ([]byte, error) {
+	publicKey, err := pub.Key()
+	if err != nil {
+		return nil, err
+	}
+	return chunked(plaintext, publicKey.Size()-2*sha256.Size-2, func(chunk []byte) ([]byte, error) {
+		return rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, chunk, label)
+	})
 }
 
 func (pri *rsaPri) i() {}
 
-func (pri *rsaPri) Decrypt(decryptStr string) (string, error) {
-	// pem 解码
-	block, _ := pem.Decode([]byte(pri.PrivateKey))
+func (pri *rsaPri) Key() (*rsa.PrivateKey, error) {
+	pri.once.Do(func() {
+		if pri.key == nil {
+			if pri.key, pri.err = ParsePrivateKey(pri.PrivateKey); pri.err == nil {
+				pri.key.Precompute()
+			}
+		}
+	})
+	return pri.key, pri.err
+}
+
+func (pri *rsaPri) Public() Public {
+	key, err := pri.Key()
+	if err != nil {
+		return &rsaPub{err: err}
+	}
+	return &rsaPub{key: &key.PublicKey}
+}
 
-	// X509 解码
-	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+// Decrypt accepts URL and standard base64, the latter being the output of
+// JSEncrypt in browsers.
+func (pri *rsaPri) Decrypt(decryptStr string) (string, error) {
+	privateKey, err := pri.Key()
 	if err != nil {
 		return "", err
 	}
+
 	decryptBytes, err := base64.URLEncoding.DecodeString(decryptStr)
+	if err != nil {
+		if decryptBytes, err = base64.StdEncoding.DecodeString(decryptStr); err != nil {
+			return "", err
+		}
+	}
 
-	//对密文进行解密
-	decrypted, _ := rsa.DecryptPKCS1v15(rand.Reader, privateKey, decryptBytes)
+	//对密文进行分段解密
+	decrypted, err := unchunked(decryptBytes, privateKey.Size(), func(chunk []byte) ([]byte, error) {
+		return rsa.DecryptPKCS1v15(nil, privateKey, chunk)
+	})
+	if err != nil {
+		return "", err
+	}
 
 	//返回明文
 	return string(decrypted), nil
 }
+
+func (pri *rsaPri) DecryptOAEP(ciphertext, label []byte) ([]byte, error) {
+	privateKey, err := pri.Key()
+	if err != nil {
+		return nil, err
+	}
+	return unchunked(ciphertext, privateKey.Size(), func(chunk []byte) ([]byte, error) {
+		return rsa.DecryptOAEP(sha256.New(), nil, privateKey, chunk, label)
+	})
+}
+
+// chunked encrypts plaintext in chunks of at most size bytes and
+// concatenates the ciphertexts, which all have the length of the modulus.
+func chunked(plaintext []byte, size int, encrypt func([]byte) ([]byte, error)) ([]byte, error) {
+	if size <= 0 {
+		return nil, errors.New("rsa: key too small")
+	}
+
+	var out []byte
+	for first := true; first || len(plaintext) > 0; first = false {
+		n := min(size, len(plaintext))
+		ct, err := encrypt(plaintext[:n])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ct...)
+		plaintext = plaintext[n:]
+	}
+	return out, nil
+}
+
+func unchunked(ciphertext []byte, size int, decrypt func([]byte) ([]byte, error)) ([]byte, error) {
+	if len(ciphertext) == 0 || len(ciphertext)%size != 0 {
+		return nil, rsa.ErrDecryption
+	}
+
+	var out []byte
+	for ; len(ciphertext) > 0; ciphertext = ciphertext[size:] {
+		pt, err := decrypt(ciphertext[:size])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pt...)
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Scheme is an RSA signature scheme, both with SHA-256.
+type Scheme int
+
+const (
+	// PSS is RSASSA-PSS, preferred for new protocols.
+	PSS Scheme = iota
+	// PKCS1v15 is RSASSA-PKCS1-v1_5, the RS256 of JWT and most webhooks.
+	PKCS1v15
+)
+
+// pssOptions uses the salt length of the hash, which is what most other
+// implementations expect when verifying.
+var pssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+
+func (pri *rsaPri) Sign(data []byte, scheme Scheme) ([]byte, error) {
+	privateKey, err := pri.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(data)
+	switch scheme {
+	case PSS:
+		return rsa.SignPSS(rand.Reader, privateKey, crypto.SHA256, digest[:], pssOptions)
+	case PKCS1v15:
+		return rsa.SignPKCS1v15(nil, privateKey, crypto.SHA256, digest[:])
+	}
+	return nil, fmt.Errorf("rsa: unknown signature scheme %d", scheme)
+}
+
+// Verify returns rsa.ErrVerification for an invalid signature.
+func (pub *rsaPub) Verify(data, sig []byte, scheme Scheme) error {
+	publicKey, err := pub.Key()
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	switch scheme {
+	case PSS:
+		// accept any salt length when verifying
+		return rsa.VerifyPSS(publicKey, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{Hash: crypto.SHA256})
+	case PKCS1v15:
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], sig)
+	}
+	return fmt.Errorf("rsa: unknown signature scheme %d", scheme)
+}
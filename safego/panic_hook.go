@@ -0,0 +1,206 @@
+package safego
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v-mars/library/logs"
+)
+
+// StackFrame is one frame of the stack that panicked.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// PanicInfo describes a recovered panic passed to panic handlers.
+type PanicInfo struct {
+	Value    any
+	Stack    []StackFrame
+	RawStack []byte
+	Time     time.Time
+	// Suppressed is the number of identical panics dropped by the dedup
+	// window since this panic was last reported.
+	Suppressed int
+}
+
+// Error implements the error interface, so PanicInfo can be returned as is.
+func (p *PanicInfo) Error() string {
+	return NewPanicErr(p.Value, p.RawStack).Error()
+}
+
+// PanicHandler is invoked for every reported panic. Handlers must not block.
+type PanicHandler func(ctx context.Context, info *PanicInfo)
+
+type dedupEntry struct {
+	last       time.Time
+	suppressed int
+}
+
+var (
+	hookMu      sync.RWMutex
+	hooks       = map[string]PanicHandler{}
+	dedupWindow time.Duration
+	dedupSeen   = map[string]*dedupEntry{}
+)
+
+// RegisterPanicHandler registers h under name, replacing any handler already
+// registered with the same name.
+func RegisterPanicHandler(name string, h PanicHandler) {
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	hooks[name] = h
+}
+
+// UnregisterPanicHandler removes the handler registered under name.
+func UnregisterPanicHandler(name string) {
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	delete(hooks, name)
+}
+
+// SetPanicDedupWindow sets the window during which panics with the same value
+// and origin are reported to handlers only once. 0 disables dedup.
+func SetPanicDedupWindow(d time.Duration) {
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	dedupWindow = d
+	dedupSeen = map[string]*dedupEntry{}
+}
+
+// Recovery recovers a panic, logs it and reports it to the registered panic
+// handlers. It must be called directly with defer.
+func Recovery(ctx context.Context) {
+	e := recover()
+	if e == nil {
+		return
+	}
+
+	HandlePanic(ctx, e)
+}
+
+// HandlePanic logs a value obtained from recover and reports it to the
+// registered panic handlers. It should be called from the deferred function
+// that recovered, so the captured stack still points at the panic site.
+func HandlePanic(ctx context.Context, e any) *PanicInfo {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	info := &PanicInfo{
+		Value:    e,
+		Stack:    panicStack(),
+		RawStack: debug.Stack(),
+		Time:     time.Now(),
+	}
+	logs.CtxErrorf(ctx, "[catch panic] err = %v \n stacktrace:\n%s", e, info.RawStack)
+
+	handlers, ok := acquire(ctx, info)
+	if !ok {
+		return info
+	}
+	for _, h := range handlers {
+		invoke(ctx, h, info)
+	}
+
+	return info
+}
+
+func acquire(ctx context.Context, info *PanicInfo) ([]PanicHandler, bool) {
+	hookMu.Lock()
+	defer hookMu.Unlock()
+
+	if len(hooks) == 0 {
+		return nil, false
+	}
+
+	if dedupWindow > 0 {
+		key := dedupKey(info)
+		if ent, ok := dedupSeen[key]; ok && info.Time.Sub(ent.last) < dedupWindow {
+			ent.suppressed++
+			return nil, false
+		} else if ok {
+			info.Suppressed = ent.suppressed
+		}
+		dedupSeen[key] = &dedupEntry{last: info.Time}
+		// prune the expired entries, whose panics did not happen again, so
+		// that the map does not grow with every distinct panic; their
+		// suppressed count would otherwise be lost
+		for k, ent := range dedupSeen {
+			if info.Time.Sub(ent.last) < dedupWindow {
+				continue
+			}
+			if ent.suppressed > 0 {
+				logs.CtxWarnf(ctx, "[catch panic] %d more panics suppressed: %s", ent.suppressed, k)
+			}
+			delete(dedupSeen, k)
+		}
+	}
+
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	handlers := make([]PanicHandler, 0, len(names))
+	for _, name := range names {
+		handlers = append(handlers, hooks[name])
+	}
+	return handlers, true
+}
+
+func invoke(ctx context.Context, h PanicHandler, info *PanicInfo) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.CtxErrorf(ctx, "[catch panic] panic handler panicked: %v", r)
+		}
+	}()
+
+	h(ctx, info)
+}
+
+func dedupKey(info *PanicInfo) string {
+	key := fmt.Sprintf("%v", info.Value)
+	if len(info.Stack) > 0 {
+		key += fmt.Sprintf("@%s:%d", info.Stack[0].File, info.Stack[0].Line)
+	}
+	return key
+}
+
+// panicStack returns the frames below runtime.gopanic, i.e. starting at the
+// function that panicked.
+func panicStack() []StackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []StackFrame
+	found := false
+	for {
+		f, more := frames.Next()
+		if found {
+			stack = append(stack, StackFrame{Function: f.Function, File: f.File, Line: f.Line})
+		} else if f.Function == "runtime.gopanic" {
+			found = true
+		}
+		if !more {
+			break
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	for len(stack) > 0 && strings.HasPrefix(stack[0].Function, "runtime.") {
+		stack = stack[1:]
+	}
+	return stack
+}
@@ -0,0 +1,97 @@
+package safego
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func panicAt() {
+	panic("hook test")
+}
+
+func TestPanicHandler(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		infos []*PanicInfo
+	)
+	RegisterPanicHandler("test", func(ctx context.Context, info *PanicInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, info)
+	})
+	defer UnregisterPanicHandler("test")
+
+	// the handlers run once fn has unwound, so wait for the last of them,
+	// handlers being called in name order
+	var wg sync.WaitGroup
+	wg.Add(1)
+	RegisterPanicHandler("wait", func(ctx context.Context, info *PanicInfo) {
+		wg.Done()
+	})
+	defer UnregisterPanicHandler("wait")
+
+	Go(context.Background(), panicAt)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, "hook test", infos[0].Value)
+		if assert.NotEmpty(t, infos[0].Stack) {
+			assert.True(t, strings.HasSuffix(infos[0].Stack[0].Function, "safego.panicAt"))
+		}
+	}
+}
+
+func TestPanicDedup(t *testing.T) {
+	SetPanicDedupWindow(50 * time.Millisecond)
+	defer SetPanicDedupWindow(0)
+
+	var got []int
+	RegisterPanicHandler("dedup", func(ctx context.Context, info *PanicInfo) {
+		got = append(got, info.Suppressed)
+	})
+	defer UnregisterPanicHandler("dedup")
+
+	fire := func() {
+		defer Recovery(context.Background())
+		panicAt()
+	}
+	for i := 0; i < 5; i++ {
+		fire()
+	}
+	assert.Equal(t, []int{0}, got)
+
+	time.Sleep(60 * time.Millisecond)
+	fire()
+	assert.Equal(t, []int{0, 4}, got)
+
+	// expired entries are pruned even when they suppressed panics
+	fire()
+	time.Sleep(60 * time.Millisecond)
+	func() {
+		defer Recovery(context.Background())
+		panic("other")
+	}()
+	assert.Equal(t, []int{0, 4, 0}, got)
+	hookMu.RLock()
+	assert.Len(t, dedupSeen, 1)
+	hookMu.RUnlock()
+}
+
+func TestPanicHandlerPanics(t *testing.T) {
+	RegisterPanicHandler("bad", func(ctx context.Context, info *PanicInfo) {
+		panic("handler")
+	})
+	defer UnregisterPanicHandler("bad")
+
+	assert.NotPanics(t, func() {
+		defer Recovery(context.Background())
+		panicAt()
+	})
+}
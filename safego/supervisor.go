@@ -0,0 +1,225 @@
+package safego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/v-mars/library/lang/signal"
+	"github.com/v-mars/library/logs"
+)
+
+var (
+	// ErrShutdownTimeout is returned by Supervisor.Shutdown when workers do
+	// not drain before the deadline.
+	ErrShutdownTimeout = errors.New("supervisor: shutdown timeout")
+
+	// ErrSupervisorClosed is returned by Supervisor.Go once the supervisor
+	// is shutting down.
+	ErrSupervisorClosed = errors.New("supervisor: closed")
+)
+
+// Worker is a long-running task owned by a Supervisor. It must return
+// when ctx is done.
+type Worker func(ctx context.Context) error
+
+// RestartPolicy decides whether a worker is started again after it returns.
+type RestartPolicy int
+
+const (
+	// RestartOnFailure restarts the worker when it panics or returns an error.
+	RestartOnFailure RestartPolicy = iota
+	// RestartAlways restarts the worker whenever it returns, including nil.
+	RestartAlways
+	// RestartNever runs the worker only once.
+	RestartNever
+)
+
+// Backoff is an exponential delay between restarts. The delay is reset
+// to Min once a worker has been running for longer than Max. Delays are at
+// least minBackoff, so that a crashing worker never restarts in a tight
+// loop, and a Factor below 1 keeps the delay constant.
+type Backoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+}
+
+// DefaultBackoff is used by workers that do not set their own backoff.
+var DefaultBackoff = Backoff{Min: 100 * time.Millisecond, Max: 30 * time.Second, Factor: 2}
+
+// minBackoff is the floor of the delays of a Backoff.
+const minBackoff = time.Millisecond
+
+func (b Backoff) next(cur time.Duration) time.Duration {
+	lo := max(b.Min, minBackoff)
+	if cur <= 0 {
+		return lo
+	}
+	n := max(time.Duration(float64(cur)*max(b.Factor, 1)), lo)
+	if n > b.Max {
+		return max(b.Max, lo)
+	}
+	return n
+}
+
+type workerOptions struct {
+	policy      RestartPolicy
+	backoff     Backoff
+	maxRestarts int
+}
+
+// WorkerOption configures a worker added to a Supervisor.
+type WorkerOption func(o *workerOptions)
+
+// WithRestartPolicy sets the restart policy, RestartOnFailure by default.
+func WithRestartPolicy(p RestartPolicy) WorkerOption {
+	return func(o *workerOptions) {
+		o.policy = p
+	}
+}
+
+// WithBackoff sets the delay between restarts.
+func WithBackoff(b Backoff) WorkerOption {
+	return func(o *workerOptions) {
+		o.backoff = b
+	}
+}
+
+// WithMaxRestarts limits how many times a worker is restarted, 0 means unlimited.
+func WithMaxRestarts(n int) WorkerOption {
+	return func(o *workerOptions) {
+		o.maxRestarts = n
+	}
+}
+
+// Supervisor owns a set of long-running workers. Workers are restarted on
+// panic or error according to their policy and are all stopped when the
+// supervisor's context is cancelled or Shutdown is called.
+type Supervisor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex // orders Go against Shutdown
+}
+
+// NewSupervisor creates a supervisor whose workers live until ctx is done.
+func NewSupervisor(ctx context.Context) *Supervisor {
+	s := &Supervisor{}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	return s
+}
+
+// Go starts a named worker under the supervisor. It returns
+// ErrSupervisorClosed, and does not start w, once the supervisor is
+// shutting down.
+func (s *Supervisor) Go(name string, w Worker, opts ...WorkerOption) error {
+	o := &workerOptions{policy: RestartOnFailure, backoff: DefaultBackoff}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// the worker is added under the lock so that Shutdown never waits
+	// while a worker is being added
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx.Err() != nil {
+		return ErrSupervisorClosed
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(name, w, o)
+	}()
+	return nil
+}
+
+func (s *Supervisor) supervise(name string, w Worker, o *workerOptions) {
+	var delay time.Duration
+	for restarts := 0; ; restarts++ {
+		start := time.Now()
+		err := s.run(w)
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case o.policy == RestartNever:
+			return
+		case o.policy == RestartOnFailure && err == nil:
+			return
+		case o.maxRestarts > 0 && restarts >= o.maxRestarts:
+			logs.CtxErrorf(s.ctx, "[Supervisor] worker %s exceeded %d restarts, last err = %v", name, o.maxRestarts, err)
+			return
+		}
+
+		if time.Since(start) > o.backoff.Max {
+			delay = 0
+		}
+		delay = o.backoff.next(delay)
+		logs.CtxWarnf(s.ctx, "[Supervisor] worker %s exited, err = %v, restart in %s", name, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *Supervisor) run(w Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = HandlePanic(s.ctx, r)
+		}
+	}()
+
+	return w(s.ctx)
+}
+
+// Done returns a channel closed when the supervisor starts shutting down.
+func (s *Supervisor) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Shutdown cancels all workers and waits up to timeout for them to return.
+// A non-positive timeout waits forever.
+func (s *Supervisor) Shutdown(timeout time.Duration) error {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	if timeout <= 0 {
+		<-done
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("%w after %s", ErrShutdownTimeout, timeout)
+	}
+}
+
+// WaitExit blocks until an exit signal (SIGINT, SIGHUP, SIGTERM) is received
+// or the supervisor's context is done, then shuts down within timeout.
+func (s *Supervisor) WaitExit(timeout time.Duration) error {
+	if sig := signal.WaitExitCtx(s.ctx); sig != nil {
+		logs.CtxInfof(s.ctx, "[Supervisor] received signal %v, shutting down", sig)
+	}
+
+	return s.Shutdown(timeout)
+}
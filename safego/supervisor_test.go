@@ -0,0 +1,101 @@
+package safego
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fastBackoff = Backoff{Min: time.Millisecond, Max: 5 * time.Millisecond, Factor: 2}
+
+func TestSupervisorRestartOnPanic(t *testing.T) {
+	s := NewSupervisor(context.Background())
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	s.Go("panicky", func(ctx context.Context) error {
+		if runs.Add(1) < 3 {
+			panic("boom")
+		}
+		close(done)
+		<-ctx.Done()
+		return nil
+	}, WithBackoff(fastBackoff))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker was not restarted")
+	}
+	assert.NoError(t, s.Shutdown(time.Second))
+	assert.Equal(t, int32(3), runs.Load())
+}
+
+func TestSupervisorPolicies(t *testing.T) {
+	s := NewSupervisor(context.Background())
+
+	var onFailure, never, limited atomic.Int32
+	s.Go("ok", func(ctx context.Context) error {
+		onFailure.Add(1)
+		return nil
+	}, WithBackoff(fastBackoff))
+	s.Go("never", func(ctx context.Context) error {
+		never.Add(1)
+		return errors.New("fail")
+	}, WithRestartPolicy(RestartNever))
+	s.Go("limited", func(ctx context.Context) error {
+		limited.Add(1)
+		return errors.New("fail")
+	}, WithBackoff(fastBackoff), WithMaxRestarts(2))
+
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, s.Shutdown(time.Second))
+	assert.Equal(t, int32(1), onFailure.Load())
+	assert.Equal(t, int32(1), never.Load())
+	assert.Equal(t, int32(3), limited.Load())
+}
+
+func TestSupervisorShutdownTimeout(t *testing.T) {
+	s := NewSupervisor(context.Background())
+
+	release := make(chan struct{})
+	defer close(release)
+	s.Go("stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	err := s.Shutdown(10 * time.Millisecond)
+	assert.ErrorIs(t, err, ErrShutdownTimeout)
+}
+
+func TestSupervisorWaitExitOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSupervisor(ctx)
+	s.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	cancel()
+	assert.NoError(t, s.WaitExit(time.Second))
+	assert.ErrorIs(t, s.Go("late", func(ctx context.Context) error { return nil }), ErrSupervisorClosed)
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: 10 * time.Millisecond, Max: 30 * time.Millisecond, Factor: 2}
+	assert.Equal(t, 10*time.Millisecond, b.next(0))
+	assert.Equal(t, 20*time.Millisecond, b.next(10*time.Millisecond))
+	assert.Equal(t, 30*time.Millisecond, b.next(20*time.Millisecond))
+
+	// a zero backoff never restarts in a tight loop
+	var zero Backoff
+	assert.Equal(t, minBackoff, zero.next(0))
+	assert.Equal(t, minBackoff, zero.next(minBackoff))
+	noFactor := Backoff{Min: 5 * time.Millisecond, Max: time.Second}
+	assert.Equal(t, 5*time.Millisecond, noFactor.next(5*time.Millisecond))
+}
@@ -0,0 +1,75 @@
+package signing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryNonceStore is a NonceStore for a single instance.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	sweep  time.Time
+	now    func() time.Time
+}
+
+// NewMemoryNonceStore creates an empty MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: map[string]time.Time{}, now: time.Now}
+}
+
+func (s *MemoryNonceStore) Seen(_ context.Context, nonce string, expiry time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	// drop the expired nonces at most once a second
+	if now.Sub(s.sweep) >= time.Second {
+		for n, exp := range s.nonces {
+			if !exp.After(now) {
+				delete(s.nonces, n)
+			}
+		}
+		s.sweep = now
+	}
+
+	if exp, ok := s.nonces[nonce]; ok && exp.After(now) {
+		return true, nil
+	}
+	s.nonces[nonce] = expiry
+	return false, nil
+}
+
+// Len returns the number of nonces held.
+func (s *MemoryNonceStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.nonces)
+}
+
+// RedisNonceStore is a NonceStore shared by several instances.
+type RedisNonceStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisNonceStore creates a RedisNonceStore storing the nonces under
+// prefix + nonce.
+func NewRedisNonceStore(client redis.Cmdable, prefix string) *RedisNonceStore {
+	return &RedisNonceStore{client: client, prefix: prefix}
+}
+
+func (s *RedisNonceStore) Seen(ctx context.Context, nonce string, expiry time.Time) (bool, error) {
+	ttl := time.Until(expiry)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	ok, err := s.client.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
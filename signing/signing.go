@@ -0,0 +1,275 @@
+// Package signing signs HTTP requests and webhooks and verifies them with
+// clock skew and replay protection.
+//
+// The signature covers a canonical string of the request:
+//
+//	METHOD
+//	/escaped/path
+//	sorted=query&string=
+//	hex(sha256(body))
+//	unix timestamp
+//	nonce
+//
+// and travels in the X-Signature header, base64 encoded, along with
+// X-Timestamp, X-Nonce and an optional X-Key-Id.
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/v-mars/library/rsa"
+)
+
+const (
+	HeaderSignature = "X-Signature"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderKeyID     = "X-Key-Id"
+)
+
+var (
+	// ErrSignature is returned for requests whose signature does not match.
+	ErrSignature = errors.New("signing: invalid signature")
+
+	// ErrBodyTooLarge is returned for bodies larger than the limit, see
+	// DefaultMaxBody and WithMaxBody.
+	ErrBodyTooLarge = errors.New("signing: body too large")
+)
+
+// Signer signs canonical strings.
+type Signer interface {
+	Sign(data []byte) ([]byte, error)
+}
+
+// Verifier verifies the signatures of a Signer.
+type Verifier interface {
+	Verify(data, sig []byte) error
+}
+
+// HMAC signs with HMAC-SHA256. It is both a Signer and a Verifier.
+type HMAC []byte
+
+func (h HMAC) Sign(data []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, h)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func (h HMAC) Verify(data, sig []byte) error {
+	expected, _ := h.Sign(data)
+	if !hmac.Equal(expected, sig) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// RSASigner signs with the private key of the rsa package.
+func RSASigner(key rsa.Private, scheme rsa.Scheme) Signer {
+	return rsaSigner{key: key, scheme: scheme}
+}
+
+// RSAVerifier verifies with the public key of the rsa package.
+func RSAVerifier(key rsa.Public, scheme rsa.Scheme) Verifier {
+	return rsaVerifier{key: key, scheme: scheme}
+}
+
+type rsaSigner struct {
+	key    rsa.Private
+	scheme rsa.Scheme
+}
+
+func (s rsaSigner) Sign(data []byte) ([]byte, error) {
+	return s.key.Sign(data, s.scheme)
+}
+
+type rsaVerifier struct {
+	key    rsa.Public
+	scheme rsa.Scheme
+}
+
+func (v rsaVerifier) Verify(data, sig []byte) error {
+	if v.key.Verify(data, sig, v.scheme) != nil {
+		return ErrSignature
+	}
+	return nil
+}
+
+// BodyHash returns the hex SHA-256 of body.
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// CanonicalString builds the string that is signed.
+func CanonicalString(method, path string, query url.Values, bodyHash string, timestamp int64, nonce string) string {
+	if path == "" {
+		path = "/"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(method))
+	sb.WriteByte('\n')
+	sb.WriteString(path)
+	sb.WriteByte('\n')
+	sb.WriteString(canonicalQuery(query))
+	sb.WriteByte('\n')
+	sb.WriteString(bodyHash)
+	sb.WriteByte('\n')
+	sb.WriteString(strconv.FormatInt(timestamp, 10))
+	sb.WriteByte('\n')
+	sb.WriteString(nonce)
+	return sb.String()
+}
+
+// canonicalQuery sorts by key and then by value, unlike url.Values.Encode
+// which keeps the order of the values of a key.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+			sb.WriteString(url.QueryEscape(k))
+			sb.WriteByte('=')
+			sb.WriteString(url.QueryEscape(v))
+		}
+	}
+	return sb.String()
+}
+
+func requestCanonicalString(r *http.Request, body []byte, timestamp int64, nonce string) string {
+	return CanonicalString(r.Method, r.URL.EscapedPath(), r.URL.Query(), BodyHash(body), timestamp, nonce)
+}
+
+// readBody reads the body of r, at most limit bytes when limit > 0. With a
+// GetBody, it reads a copy and leaves the body of r unread; otherwise it
+// consumes the body and replaces it, so it can be read again.
+func readBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if r.GetBody != nil {
+		rc, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return readAll(rc, limit)
+	}
+
+	body, err := readAll(r.Body, limit)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SignRequest signs r in place, setting the X-Timestamp, X-Nonce,
+// X-Signature and, when keyID is not empty, X-Key-Id headers.
+//
+// The body is read through GetBody when r has one, as the requests of
+// http.NewRequest with a bytes or strings reader do. Otherwise it is
+// consumed and replaced with an in-memory copy. Bodies larger than
+// DefaultMaxBody, which the RequestVerifier would reject by default, fail
+// with ErrBodyTooLarge.
+func SignRequest(r *http.Request, s Signer, keyID string) error {
+	body, err := readBody(r, DefaultMaxBody)
+	if err != nil {
+		return err
+	}
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+
+	sig, err := s.Sign([]byte(requestCanonicalString(r, body, timestamp, nonce)))
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, base64.StdEncoding.EncodeToString(sig))
+	if keyID != "" {
+		r.Header.Set(HeaderKeyID, keyID)
+	}
+	return nil
+}
+
+// Transport is an http.RoundTripper signing every request, for example as
+// the Transport of the client given to utils.Request.
+type Transport struct {
+	// Base is the underlying transport, http.DefaultTransport when nil.
+	Base   http.RoundTripper
+	Signer Signer
+	KeyID  string
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request, and the clone shares its
+	// body, which SignRequest reads through GetBody when it can
+	r = r.Clone(r.Context())
+	if err := SignRequest(r, t.Signer, t.KeyID); err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
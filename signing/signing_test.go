@@ -0,0 +1,199 @@
+package signing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/v-mars/library/rsa"
+)
+
+var secret = HMAC("webhook-secret")
+
+func TestCanonicalString(t *testing.T) {
+	q1, _ := url.ParseQuery("b=2&a=3&a=1&c=x y")
+	q2, _ := url.ParseQuery("c=x+y&a=1&b=2&a=3")
+	s := CanonicalString("post", "/v1/orders", q1, BodyHash([]byte("{}")), 1700000000, "n1")
+	assert.Equal(t, s, CanonicalString("POST", "/v1/orders", q2, BodyHash([]byte("{}")), 1700000000, "n1"))
+	assert.Equal(t, "POST\n/v1/orders\na=1&a=3&b=2&c=x+y\n"+
+		"44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a\n1700000000\nn1", s)
+}
+
+func newServer(t *testing.T, v *RequestVerifier) *httptest.Server {
+	srv := httptest.NewServer(v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTransportAndMiddleware(t *testing.T) {
+	srv := newServer(t, NewRequestVerifier(secret))
+	client := &http.Client{Transport: &Transport{Signer: secret}}
+
+	resp, err := client.Post(srv.URL+"/hook?b=1&a=2", "application/json", strings.NewReader(`{"event":"paid"}`))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"event":"paid"}`, string(body))
+
+	// unsigned and wrongly signed requests
+	resp, _ = http.Post(srv.URL+"/hook", "application/json", strings.NewReader(`{}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	// the reason is not sent to the client
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "Unauthorized\n", string(body))
+	resp, _ = (&http.Client{Transport: &Transport{Signer: HMAC("other")}}).Get(srv.URL + "/hook")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func signed(t *testing.T, method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	assert.NoError(t, SignRequest(r, secret, ""))
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	v := NewRequestVerifier(secret)
+
+	r := signed(t, http.MethodPost, "/hook?a=1", "body")
+	assert.NoError(t, v.Verify(r))
+	// the body can still be read
+	body, _ := io.ReadAll(r.Body)
+	assert.Equal(t, "body", string(body))
+
+	// replay
+	replay := httptest.NewRequest(http.MethodPost, "/hook?a=1", strings.NewReader("body"))
+	replay.Header = r.Header.Clone()
+	assert.ErrorIs(t, v.Verify(replay), ErrReplay)
+
+	// tampering
+	for _, tamper := range []func(*http.Request) *http.Request{
+		func(r *http.Request) *http.Request {
+			return withHeader(httptest.NewRequest(http.MethodPost, "/hook?a=1", strings.NewReader("bodY")), r)
+		},
+		func(r *http.Request) *http.Request {
+			return withHeader(httptest.NewRequest(http.MethodPost, "/hook?a=2", strings.NewReader("body")), r)
+		},
+		func(r *http.Request) *http.Request {
+			return withHeader(httptest.NewRequest(http.MethodPut, "/hook?a=1", strings.NewReader("body")), r)
+		},
+		func(r *http.Request) *http.Request {
+			r.Header.Set(HeaderNonce, "other")
+			return r
+		},
+	} {
+		assert.ErrorIs(t, v.Verify(tamper(signed(t, http.MethodPost, "/hook?a=1", "body"))), ErrSignature)
+	}
+
+	r = signed(t, http.MethodGet, "/", "")
+	r.Header.Del(HeaderNonce)
+	assert.ErrorIs(t, v.Verify(r), ErrMissingHeader)
+}
+
+func withHeader(r, from *http.Request) *http.Request {
+	r.Header = from.Header.Clone()
+	return r
+}
+
+func TestVerifySkew(t *testing.T) {
+	v := NewRequestVerifier(secret, WithSkew(time.Minute))
+	r := signed(t, http.MethodGet, "/", "")
+	ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+
+	v.now = func() time.Time { return time.Unix(ts, 0).Add(2 * time.Minute) }
+	assert.ErrorIs(t, v.Verify(r), ErrClockSkew)
+	v.now = func() time.Time { return time.Unix(ts, 0).Add(-2 * time.Minute) }
+	assert.ErrorIs(t, v.Verify(r), ErrClockSkew)
+	v.now = func() time.Time { return time.Unix(ts, 0).Add(30 * time.Second) }
+	assert.NoError(t, v.Verify(r))
+}
+
+func TestVerifyRSAWithKeys(t *testing.T) {
+	priPEM, pubPEM, err := rsa.GenerateKeyPair(2048)
+	assert.NoError(t, err)
+
+	v := NewRequestVerifier(nil, WithKeys(func(keyID string) (Verifier, error) {
+		switch keyID {
+		case "partner-a":
+			return RSAVerifier(rsa.NewPublic(pubPEM), rsa.PSS), nil
+		case "partner-b":
+			return secret, nil
+		}
+		return nil, ErrUnknownKey
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("x"))
+	assert.NoError(t, SignRequest(r, RSASigner(rsa.NewPrivate(priPEM), rsa.PSS), "partner-a"))
+	assert.NoError(t, v.Verify(r))
+
+	r = httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("x"))
+	assert.NoError(t, SignRequest(r, secret, "partner-a"))
+	assert.ErrorIs(t, v.Verify(r), ErrSignature)
+
+	r = httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("x"))
+	assert.NoError(t, SignRequest(r, secret, "partner-c"))
+	assert.ErrorIs(t, v.Verify(r), ErrUnknownKey)
+}
+
+func TestVerifyMaxBody(t *testing.T) {
+	v := NewRequestVerifier(secret, WithMaxBody(4))
+	assert.ErrorIs(t, v.Verify(signed(t, http.MethodPost, "/", "too long")), ErrBodyTooLarge)
+	assert.NoError(t, v.Verify(signed(t, http.MethodPost, "/", "ok")))
+
+	r := httptest.NewRequest(http.MethodPost, "/", io.LimitReader(neverEnding('x'), DefaultMaxBody+1))
+	assert.ErrorIs(t, SignRequest(r, secret, ""), ErrBodyTooLarge)
+}
+
+type neverEnding byte
+
+func (b neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+	return len(p), nil
+}
+
+func TestSignRequestGetBody(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "http://example.com/hook", strings.NewReader("body"))
+	body := r.Body
+	assert.NoError(t, SignRequest(r, secret, ""))
+	// the body was read through GetBody and is left unread
+	assert.Equal(t, body, r.Body)
+	b, _ := io.ReadAll(r.Body)
+	assert.Equal(t, "body", string(b))
+
+	srv := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("body"))
+	srv.Header = r.Header
+	assert.NoError(t, NewRequestVerifier(secret).Verify(srv))
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	s := NewMemoryNonceStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	seen, _ := s.Seen(ctx, "a", now.Add(time.Minute))
+	assert.False(t, seen)
+	seen, _ = s.Seen(ctx, "a", now.Add(time.Minute))
+	assert.True(t, seen)
+	_, _ = s.Seen(ctx, "b", now.Add(time.Minute))
+	assert.Equal(t, 2, s.Len())
+
+	now = now.Add(2 * time.Minute)
+	seen, _ = s.Seen(ctx, "a", now.Add(time.Minute))
+	assert.False(t, seen)
+	assert.Equal(t, 1, s.Len())
+}
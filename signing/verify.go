@@ -0,0 +1,161 @@
+package signing
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/v-mars/library/logs"
+)
+
+var (
+	// ErrMissingHeader is returned for requests without signature headers.
+	ErrMissingHeader = errors.New("signing: missing signature header")
+
+	// ErrClockSkew is returned for requests whose timestamp is too old or
+	// too far in the future.
+	ErrClockSkew = errors.New("signing: timestamp outside the allowed skew")
+
+	// ErrReplay is returned for requests whose nonce was already used.
+	ErrReplay = errors.New("signing: nonce already used")
+
+	// ErrUnknownKey is returned when no verifier matches the key ID.
+	ErrUnknownKey = errors.New("signing: unknown key id")
+)
+
+const (
+	// DefaultSkew is the default maximum difference between the timestamp
+	// of a request and the local clock.
+	DefaultSkew = 5 * time.Minute
+
+	// DefaultMaxBody is the default maximum body size read for verification.
+	DefaultMaxBody = 10 << 20
+)
+
+// RequestVerifier verifies signed requests.
+type RequestVerifier struct {
+	keys    func(keyID string) (Verifier, error)
+	skew    time.Duration
+	store   NonceStore
+	maxBody int64
+	now     func() time.Time
+}
+
+// VerifierOption configures a RequestVerifier.
+type VerifierOption func(*RequestVerifier)
+
+// WithSkew sets the allowed clock skew, DefaultSkew by default.
+func WithSkew(d time.Duration) VerifierOption {
+	return func(v *RequestVerifier) { v.skew = d }
+}
+
+// WithNonceStore sets the store of used nonces, a MemoryNonceStore by
+// default. Use a shared store when running several instances.
+func WithNonceStore(s NonceStore) VerifierOption {
+	return func(v *RequestVerifier) { v.store = s }
+}
+
+// WithMaxBody limits the size of the bodies read, DefaultMaxBody by default.
+func WithMaxBody(n int64) VerifierOption {
+	return func(v *RequestVerifier) { v.maxBody = n }
+}
+
+// WithKeys looks up the verifier by the X-Key-Id header, for example per
+// tenant or during a key rotation. It replaces the verifier of
+// NewRequestVerifier.
+func WithKeys(keys func(keyID string) (Verifier, error)) VerifierOption {
+	return func(v *RequestVerifier) { v.keys = keys }
+}
+
+// NewRequestVerifier creates a RequestVerifier checking signatures with
+// verifier.
+func NewRequestVerifier(verifier Verifier, opts ...VerifierOption) *RequestVerifier {
+	v := &RequestVerifier{
+		keys:    func(string) (Verifier, error) { return verifier, nil },
+		skew:    DefaultSkew,
+		maxBody: DefaultMaxBody,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	if v.store == nil {
+		v.store = NewMemoryNonceStore()
+	}
+	return v
+}
+
+// Verify checks the signature, the timestamp and the nonce of r. The body
+// of r is read and replaced, so handlers can still read it.
+func (v *RequestVerifier) Verify(r *http.Request) error {
+	timestampHeader, nonce := r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce)
+	sigHeader := r.Header.Get(HeaderSignature)
+	if timestampHeader == "" || nonce == "" || sigHeader == "" {
+		return ErrMissingHeader
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrClockSkew, timestampHeader)
+	}
+	signedAt := time.Unix(timestamp, 0)
+	if d := v.now().Sub(signedAt); d > v.skew || d < -v.skew {
+		return ErrClockSkew
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(sigHeader)
+	if err != nil {
+		return ErrSignature
+	}
+	verifier, err := v.keys(r.Header.Get(HeaderKeyID))
+	if err != nil {
+		return err
+	}
+	if verifier == nil {
+		return ErrUnknownKey
+	}
+
+	body, err := readBody(r, v.maxBody)
+	if err != nil {
+		return err
+	}
+	if err = verifier.Verify([]byte(requestCanonicalString(r, body, timestamp, nonce)), sig); err != nil {
+		return ErrSignature
+	}
+
+	// only record the nonces of valid requests, so that forged requests
+	// cannot burn the nonces of legitimate ones; the nonce is kept until
+	// the timestamp falls out of the allowed skew
+	seen, err := v.store.Seen(r.Context(), nonce, signedAt.Add(v.skew))
+	if err != nil {
+		return err
+	}
+	if seen {
+		return ErrReplay
+	}
+	return nil
+}
+
+// Middleware rejects the requests that fail Verify with 401 Unauthorized.
+// The reason is logged, not sent to the client.
+func (v *RequestVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			logs.CtxWarnf(r.Context(), "[signing] %s %s rejected, err = %v", r.Method, r.URL.Path, err)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NonceStore records used nonces.
+type NonceStore interface {
+	// Seen records nonce until expiry and reports whether it was already
+	// recorded.
+	Seen(ctx context.Context, nonce string, expiry time.Time) (bool, error)
+}
@@ -0,0 +1,261 @@
+// Package snowflake generates Snowflake-style distributed int64 IDs: a
+// timestamp, a node ID and a per-millisecond sequence packed into 63 bits,
+// so the IDs of all nodes are unique and roughly ordered by time.
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/v-mars/library/utils"
+)
+
+var (
+	// ErrClockBackwards is returned when the clock moved backwards further
+	// than the generator is allowed to wait.
+	ErrClockBackwards = errors.New("snowflake: clock moved backwards")
+
+	// ErrTimeOverflow is returned once the timestamp no longer fits its bits.
+	ErrTimeOverflow = errors.New("snowflake: timestamp overflow")
+)
+
+// DefaultEpoch is the epoch used when Options.Epoch is zero, 2020-01-01 UTC.
+var DefaultEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Layout is the bit layout of an ID. The three widths must add up to 63.
+type Layout struct {
+	TimeBits uint8
+	NodeBits uint8
+	SeqBits  uint8
+}
+
+// DefaultLayout is the classic 41 bit milliseconds, 10 bit node and 12 bit
+// sequence layout: 1024 nodes, 4096 IDs per millisecond per node, 69 years.
+var DefaultLayout = Layout{TimeBits: 41, NodeBits: 10, SeqBits: 12}
+
+// ClockPolicy decides what Generate does when the clock moves backwards.
+type ClockPolicy int
+
+const (
+	// ClockWait sleeps until the clock catches up, up to Options.MaxWait.
+	ClockWait ClockPolicy = iota
+	// ClockFail returns ErrClockBackwards immediately.
+	ClockFail
+)
+
+// Options configures a Node.
+type Options struct {
+	// Epoch is the zero time of the IDs, DefaultEpoch when zero.
+	Epoch time.Time
+	// Layout of the IDs, DefaultLayout when zero.
+	Layout Layout
+	// Node is the ID of this generator, unique among all generators.
+	Node int64
+	// AutoNode derives Node from the low bits of the local IPv4 address.
+	AutoNode bool
+	// ClockPolicy applies when the clock moves backwards.
+	ClockPolicy ClockPolicy
+	// MaxWait bounds the wait of ClockWait, and of Generate for the next
+	// millisecond once the sequence is exhausted, 1s when zero.
+	MaxWait time.Duration
+}
+
+// ID is a Snowflake ID.
+type ID int64
+
+// String returns the decimal form of id.
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// Parts are the fields of an ID.
+type Parts struct {
+	Time time.Time
+	Node int64
+	Seq  int64
+}
+
+// Node generates IDs. It is safe for concurrent use.
+type Node struct {
+	epoch   int64 // unix milliseconds
+	layout  Layout
+	node    int64
+	policy  ClockPolicy
+	maxWait time.Duration
+
+	mu   sync.Mutex
+	last int64 // milliseconds since epoch of the last ID
+	seq  int64
+
+	now func() time.Time
+}
+
+// NewNode creates a generator.
+func NewNode(opts Options) (*Node, error) {
+	n := &Node{
+		epoch:   opts.Epoch.UnixMilli(),
+		layout:  opts.Layout,
+		node:    opts.Node,
+		policy:  opts.ClockPolicy,
+		maxWait: opts.MaxWait,
+		last:    -1,
+		now:     time.Now,
+	}
+	if opts.Epoch.IsZero() {
+		n.epoch = DefaultEpoch.UnixMilli()
+	}
+	if n.layout == (Layout{}) {
+		n.layout = DefaultLayout
+	}
+	if n.maxWait <= 0 {
+		n.maxWait = time.Second
+	}
+
+	l := n.layout
+	if int(l.TimeBits)+int(l.NodeBits)+int(l.SeqBits) != 63 || l.TimeBits == 0 || l.SeqBits == 0 {
+		return nil, fmt.Errorf("snowflake: invalid layout %+v, the bits must add up to 63", l)
+	}
+	if n.epoch > time.Now().UnixMilli() {
+		return nil, errors.New("snowflake: epoch is in the future")
+	}
+
+	if opts.AutoNode {
+		node, err := NodeFromLocalIP(l.NodeBits)
+		if err != nil {
+			return nil, err
+		}
+		n.node = node
+	}
+	if n.node < 0 || n.node > 1<<l.NodeBits-1 {
+		return nil, fmt.Errorf("snowflake: node %d out of range [0, %d]", n.node, 1<<l.NodeBits-1)
+	}
+	return n, nil
+}
+
+// NodeFromIP returns the low bits of an IPv4 address as a node ID.
+func NodeFromIP(ip string, bits uint8) (int64, error) {
+	ipv4 := net.ParseIP(ip).To4()
+	if ipv4 == nil {
+		return 0, fmt.Errorf("snowflake: %q is not an IPv4 address", ip)
+	}
+	v := int64(ipv4[0])<<24 | int64(ipv4[1])<<16 | int64(ipv4[2])<<8 | int64(ipv4[3])
+	return v & (1<<bits - 1), nil
+}
+
+// NodeFromLocalIP returns the low bits of utils.GetLocalIPv4Address.
+func NodeFromLocalIP(bits uint8) (int64, error) {
+	ip, err := utils.GetLocalIPv4Address()
+	if err != nil {
+		return 0, fmt.Errorf("snowflake: %w", err)
+	}
+	return NodeFromIP(ip, bits)
+}
+
+// Node returns the node ID of the generator.
+func (n *Node) Node() int64 {
+	return n.node
+}
+
+func (n *Node) millis() int64 {
+	return n.now().UnixMilli() - n.epoch
+}
+
+// Generate returns a new ID.
+func (n *Node) Generate() (ID, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.millis()
+	if now < n.last {
+		behind := time.Duration(n.last-now) * time.Millisecond
+		if n.policy == ClockFail || behind > n.maxWait {
+			return 0, fmt.Errorf("%w by %s", ErrClockBackwards, behind)
+		}
+		time.Sleep(behind)
+		var err error
+		if now, err = n.waitFor(n.last); err != nil {
+			return 0, err
+		}
+	}
+
+	if now == n.last {
+		n.seq = (n.seq + 1) & (1<<n.layout.SeqBits - 1)
+		if n.seq == 0 {
+			// sequence exhausted, wait for the next millisecond
+			var err error
+			if now, err = n.waitFor(n.last + 1); err != nil {
+				// stay exhausted, the IDs of n.last are all taken
+				n.seq = 1<<n.layout.SeqBits - 1
+				return 0, err
+			}
+		}
+	} else {
+		n.seq = 0
+	}
+
+	if now >= 1<<n.layout.TimeBits {
+		return 0, ErrTimeOverflow
+	}
+	n.last = now
+
+	id := now<<(n.layout.NodeBits+n.layout.SeqBits) | n.node<<n.layout.SeqBits | n.seq
+	return ID(id), nil
+}
+
+// waitFor waits until the clock reaches ms, for at most maxWait, so that a
+// clock going backwards never blocks Generate for long.
+func (n *Node) waitFor(ms int64) (int64, error) {
+	deadline := time.Now().Add(n.maxWait)
+	for {
+		now := n.millis()
+		if now >= ms {
+			return now, nil
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("%w by %s", ErrClockBackwards, time.Duration(ms-now)*time.Millisecond)
+		}
+		if ms-now > 1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// MustGenerate is like Generate but panics on error.
+func (n *Node) MustGenerate() ID {
+	id, err := n.Generate()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// Decode splits id into its fields using the epoch and layout of n.
+func (n *Node) Decode(id ID) Parts {
+	return decode(id, n.epoch, n.layout)
+}
+
+// Decode splits id into its fields. A zero epoch or layout stands for
+// DefaultEpoch or DefaultLayout.
+func Decode(id ID, epoch time.Time, layout Layout) Parts {
+	if epoch.IsZero() {
+		epoch = DefaultEpoch
+	}
+	if layout == (Layout{}) {
+		layout = DefaultLayout
+	}
+	return decode(id, epoch.UnixMilli(), layout)
+}
+
+func decode(id ID, epoch int64, l Layout) Parts {
+	v := int64(id)
+	ms := v >> (l.NodeBits + l.SeqBits)
+	return Parts{
+		Time: time.UnixMilli(epoch + ms),
+		Node: v >> l.SeqBits & (1<<l.NodeBits - 1),
+		Seq:  v & (1<<l.SeqBits - 1),
+	}
+}
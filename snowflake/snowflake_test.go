@@ -0,0 +1,156 @@
+package snowflake
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	n, err := NewNode(Options{Node: 5})
+	assert.NoError(t, err)
+
+	before := time.Now().Truncate(time.Millisecond)
+	prev := n.MustGenerate()
+	for i := 0; i < 20000; i++ {
+		id := n.MustGenerate()
+		assert.Greater(t, id, prev)
+		prev = id
+	}
+
+	p := n.Decode(prev)
+	assert.Equal(t, int64(5), p.Node)
+	assert.False(t, p.Time.Before(before))
+	assert.WithinDuration(t, time.Now(), p.Time, time.Second)
+	assert.Equal(t, p, Decode(prev, time.Time{}, Layout{}))
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	n, _ := NewNode(Options{Node: 1})
+
+	var (
+		mu   sync.Mutex
+		seen = map[ID]bool{}
+		wg   sync.WaitGroup
+	)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]ID, 0, 5000)
+			for i := 0; i < 5000; i++ {
+				ids = append(ids, n.MustGenerate())
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range ids {
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 8*5000)
+}
+
+func TestCustomLayout(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	layout := Layout{TimeBits: 43, NodeBits: 8, SeqBits: 12}
+	n, err := NewNode(Options{Epoch: epoch, Layout: layout, Node: 255})
+	assert.NoError(t, err)
+
+	id := n.MustGenerate()
+	p := Decode(id, epoch, layout)
+	assert.Equal(t, int64(255), p.Node)
+	assert.Equal(t, int64(0), p.Seq)
+
+	_, err = NewNode(Options{Layout: layout, Node: 256})
+	assert.Error(t, err)
+	_, err = NewNode(Options{Layout: Layout{TimeBits: 41, NodeBits: 10, SeqBits: 10}})
+	assert.Error(t, err)
+	_, err = NewNode(Options{Epoch: time.Now().Add(time.Hour)})
+	assert.Error(t, err)
+}
+
+func TestClockBackwards(t *testing.T) {
+	now := time.Now()
+	n, _ := NewNode(Options{ClockPolicy: ClockFail})
+	n.now = func() time.Time { return now }
+	first := n.MustGenerate()
+
+	n.now = func() time.Time { return now.Add(-5 * time.Millisecond) }
+	_, err := n.Generate()
+	assert.ErrorIs(t, err, ErrClockBackwards)
+
+	// ClockWait sleeps until the clock catches up
+	var calls int
+	n.policy = ClockWait
+	n.now = func() time.Time {
+		calls++
+		if calls < 3 {
+			return now.Add(-5 * time.Millisecond)
+		}
+		return now.Add(time.Millisecond)
+	}
+	id, err := n.Generate()
+	assert.NoError(t, err)
+	assert.Greater(t, id, first)
+
+	n.maxWait = time.Millisecond
+	n.now = func() time.Time { return now.Add(-time.Second) }
+	_, err = n.Generate()
+	assert.ErrorIs(t, err, ErrClockBackwards)
+}
+
+func TestSequenceExhausted(t *testing.T) {
+	n, _ := NewNode(Options{Layout: Layout{TimeBits: 53, NodeBits: 8, SeqBits: 2}})
+	ids := map[ID]bool{}
+	for i := 0; i < 20; i++ {
+		ids[n.MustGenerate()] = true
+	}
+	assert.Len(t, ids, 20)
+
+	// the wait for the next millisecond is bounded when the clock stops
+	now := time.Now().Add(time.Hour) // a millisecond without IDs yet
+	n.now = func() time.Time { return now }
+	n.maxWait = 5 * time.Millisecond
+	for i := 0; i < 4; i++ {
+		ids[n.MustGenerate()] = true
+	}
+	_, err := n.Generate()
+	assert.ErrorIs(t, err, ErrClockBackwards)
+	_, err = n.Generate()
+	assert.ErrorIs(t, err, ErrClockBackwards, "still exhausted")
+
+	n.now = func() time.Time { return now.Add(time.Millisecond) }
+	ids[n.MustGenerate()] = true
+	assert.Len(t, ids, 25)
+}
+
+func TestNodeFromIP(t *testing.T) {
+	node, err := NodeFromIP("10.0.3.7", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3<<8|7), node)
+
+	_, err = NodeFromIP("::1", 10)
+	assert.Error(t, err)
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	n, _ := NewNode(Options{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = n.Generate()
+	}
+}
+
+func BenchmarkGenerateParallel(b *testing.B) {
+	n, _ := NewNode(Options{})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = n.Generate()
+		}
+	})
+}
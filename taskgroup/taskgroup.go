@@ -2,7 +2,7 @@ package taskgroup
 
 import (
 	"context"
-	"github.com/v-mars/library/logs"
+	"github.com/v-mars/library/safego"
 	"sync/atomic"
 
 	"golang.org/x/sync/errgroup"
@@ -55,7 +55,7 @@ func (t *taskGroup) Go(f func() error) {
 	t.errGroup.Go(func() error {
 		defer func() {
 			if err := recover(); err != nil {
-				logs.CtxErrorf(t.ctx, "[TaskGroup] exec panic recover:%+v", err)
+				safego.HandlePanic(t.ctx, err)
 			}
 		}()
 
@@ -0,0 +1,113 @@
+// Package token issues API keys and compact signed tokens.
+//
+// API keys look like "sk_live_" + 30 random base62 characters + a 6
+// character CRC32 checksum, so secret scanners can find them with a regexp
+// and reject false positives offline. Only a keyed hash of a key is stored.
+//
+// Signed tokens are a subset of JWT: HS256 only, with the registered claims
+// exp, nbf, iat, iss, sub, aud and jti, and any application claims.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"strings"
+
+	"github.com/v-mars/library/utils"
+)
+
+var (
+	// ErrMalformed is returned for keys and tokens that are not well formed.
+	ErrMalformed = errors.New("token: malformed")
+
+	// ErrChecksum is returned for API keys whose checksum does not match.
+	ErrChecksum = errors.New("token: checksum mismatch")
+
+	// ErrShortSecret is returned for secrets shorter than MinSecretSize.
+	ErrShortSecret = errors.New("token: secret is too short")
+)
+
+const (
+	// MinSecretSize is the minimum size of the secrets, in bytes.
+	MinSecretSize = 32
+
+	keyRandomLen   = 30
+	keyChecksumLen = 6 // 62^6 > 2^32
+)
+
+// KeyIssuer generates and verifies API keys with a fixed prefix.
+type KeyIssuer struct {
+	prefix string
+	secret []byte
+}
+
+// NewKeyIssuer creates a KeyIssuer. The prefix identifies the kind of key,
+// e.g. "sk_live", and should be unique enough for secret scanners. The
+// secret keys the stored hashes, so a leaked database does not allow
+// checking guessed keys offline.
+func NewKeyIssuer(prefix string, secret []byte) (*KeyIssuer, error) {
+	if prefix == "" || strings.Trim(prefix, utils.Base62Chars+"_") != "" {
+		return nil, errors.New("token: key prefix must be base62 characters and underscores")
+	}
+	if len(secret) < MinSecretSize {
+		return nil, ErrShortSecret
+	}
+	return &KeyIssuer{prefix: prefix + "_", secret: secret}, nil
+}
+
+// Generate returns a new key, to be shown to the user once, and its hash,
+// to be stored.
+func (k *KeyIssuer) Generate() (key, hash string) {
+	body := k.prefix + utils.Base62(keyRandomLen)
+	key = body + checksum(body)
+	return key, k.Hash(key)
+}
+
+// Hash returns the keyed hash of key, HMAC-SHA256 in hex.
+func (k *KeyIssuer) Hash(key string) string {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Check verifies the prefix and checksum of key without the secret. Use it
+// to reject typos and random strings before looking up the hash.
+func (k *KeyIssuer) Check(key string) error {
+	return CheckKey(strings.TrimSuffix(k.prefix, "_"), key)
+}
+
+// Verify reports whether key is well formed and matches the stored hash.
+func (k *KeyIssuer) Verify(key, hash string) bool {
+	if k.Check(key) != nil {
+		return false
+	}
+	return hmac.Equal([]byte(k.Hash(key)), []byte(hash))
+}
+
+// CheckKey verifies the prefix and checksum of an API key.
+func CheckKey(prefix, key string) error {
+	rest, ok := strings.CutPrefix(key, prefix+"_")
+	if !ok || len(rest) != keyRandomLen+keyChecksumLen || strings.Trim(rest, utils.Base62Chars) != "" {
+		return ErrMalformed
+	}
+
+	body := key[:len(key)-keyChecksumLen]
+	if subtle.ConstantTimeCompare([]byte(checksum(body)), []byte(key[len(body):])) != 1 {
+		return ErrChecksum
+	}
+	return nil
+}
+
+func checksum(body string) string {
+	v := crc32.ChecksumIEEE([]byte(body))
+	var buf [keyChecksumLen]byte
+	for i := keyChecksumLen - 1; i >= 0; i-- {
+		buf[i] = utils.Base62Chars[v%62]
+		v /= 62
+	}
+	return string(buf[:])
+}
@@ -0,0 +1,223 @@
+package token
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrSignature is returned for tokens with an invalid signature.
+	ErrSignature = errors.New("token: invalid signature")
+
+	// ErrAlgorithm is returned for tokens not signed with HS256.
+	ErrAlgorithm = errors.New("token: unsupported algorithm")
+
+	// ErrExpired is returned for tokens past their exp claim.
+	ErrExpired = errors.New("token: expired")
+
+	// ErrNotValidYet is returned for tokens before their nbf claim.
+	ErrNotValidYet = errors.New("token: not valid yet")
+
+	// ErrIssuer is returned for tokens from another issuer.
+	ErrIssuer = errors.New("token: invalid issuer")
+
+	// ErrAudience is returned for tokens meant for another audience.
+	ErrAudience = errors.New("token: invalid audience")
+)
+
+// Audience is the aud claim, a single string or an array in JSON.
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(a))
+}
+
+// Claims are the registered JWT claims. Embed it in a struct to add
+// application claims. The times are unix seconds.
+type Claims struct {
+	Issuer    string   `json:"iss,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Signer issues and verifies HS256 tokens.
+type Signer struct {
+	key      []byte
+	ttl      time.Duration
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// SignerOption configures a Signer.
+type SignerOption func(*Signer)
+
+// WithTTL sets the exp claim of the tokens that have none to iat + ttl.
+func WithTTL(ttl time.Duration) SignerOption {
+	return func(s *Signer) { s.ttl = ttl }
+}
+
+// WithIssuer sets the iss claim of the tokens that have none, and makes
+// Verify reject the tokens of other issuers.
+func WithIssuer(iss string) SignerOption {
+	return func(s *Signer) { s.issuer = iss }
+}
+
+// WithAudience makes Verify reject the tokens whose aud claim does not
+// contain aud.
+func WithAudience(aud string) SignerOption {
+	return func(s *Signer) { s.audience = aud }
+}
+
+// WithLeeway tolerates clock skew when checking exp and nbf.
+func WithLeeway(d time.Duration) SignerOption {
+	return func(s *Signer) { s.leeway = d }
+}
+
+// NewSigner creates a Signer with a key of at least MinSecretSize bytes.
+func NewSigner(key []byte, opts ...SignerOption) (*Signer, error) {
+	if len(key) < MinSecretSize {
+		return nil, ErrShortSecret
+	}
+	s := &Signer{key: key, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+var (
+	b64 = base64.RawURLEncoding
+	// the header never changes, only HS256 is supported
+	hs256Header = b64.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+// Sign returns a token carrying claims, which must marshal to a JSON
+// object. iat is always set; iss and exp are filled from the options when
+// claims has none.
+func (s *Signer) Sign(claims any) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("token: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return "", fmt.Errorf("%w: claims must be a JSON object", ErrMalformed)
+	}
+
+	now := s.now()
+	fields["iat"], _ = json.Marshal(now.Unix())
+	if _, ok := fields["exp"]; !ok && s.ttl > 0 {
+		fields["exp"], _ = json.Marshal(now.Add(s.ttl).Unix())
+	}
+	if _, ok := fields["iss"]; !ok && s.issuer != "" {
+		fields["iss"], _ = json.Marshal(s.issuer)
+	}
+	if payload, err = json.Marshal(fields); err != nil {
+		return "", fmt.Errorf("token: %w", err)
+	}
+
+	signed := hs256Header + "." + b64.EncodeToString(payload)
+	return signed + "." + b64.EncodeToString(s.sign(signed)), nil
+}
+
+func (s *Signer) sign(signed string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+// Verify checks the signature and the registered claims of token, then
+// unmarshals its claims into claims unless it is nil.
+func (s *Signer) Verify(token string, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrMalformed
+	}
+
+	header, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return ErrMalformed
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err = json.Unmarshal(header, &h); err != nil {
+		return ErrMalformed
+	}
+	if h.Alg != "HS256" {
+		return fmt.Errorf("%w %q", ErrAlgorithm, h.Alg)
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return ErrMalformed
+	}
+	if !hmac.Equal(sig, s.sign(parts[0]+"."+parts[1])) {
+		return ErrSignature
+	}
+
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return ErrMalformed
+	}
+	var c Claims
+	if err = json.Unmarshal(payload, &c); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if err = s.validate(&c); err != nil {
+		return err
+	}
+
+	if claims == nil {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err = dec.Decode(claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+func (s *Signer) validate(c *Claims) error {
+	now := s.now()
+	if c.ExpiresAt != 0 && !now.Before(time.Unix(c.ExpiresAt, 0).Add(s.leeway)) {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Add(s.leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrNotValidYet
+	}
+	if s.issuer != "" && !hmac.Equal([]byte(c.Issuer), []byte(s.issuer)) {
+		return ErrIssuer
+	}
+	if s.audience != "" && !slices.Contains(c.Audience, s.audience) {
+		return ErrAudience
+	}
+	return nil
+}
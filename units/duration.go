@@ -7,29 +7,56 @@ import (
 	"time"
 )
 
+// DurationUnit is the unit of an approximated duration.
+type DurationUnit string
+
+// The units returned by ApproxDuration.
+const (
+	DurationSecond DurationUnit = "second"
+	DurationMinute DurationUnit = "minute"
+	DurationHour   DurationUnit = "hour"
+	DurationDay    DurationUnit = "day"
+	DurationWeek   DurationUnit = "week"
+	DurationMonth  DurationUnit = "month"
+	DurationYear   DurationUnit = "year"
+)
+
+// ApproxDuration returns the approximation of a duration used by
+// HumanDuration as a value and its unit (eg. 4, DurationHour).
+// A value of 0 seconds means less than a second.
+func ApproxDuration(d time.Duration) (int, DurationUnit) {
+	if seconds := int(d.Seconds()); seconds < 60 {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return seconds, DurationSecond
+	} else if minutes := int(d.Minutes()); minutes < 60 {
+		return minutes, DurationMinute
+	} else if hours := int(d.Hours() + 0.5); hours < 48 {
+		return hours, DurationHour
+	} else if hours < 24*7*2 {
+		return hours / 24, DurationDay
+	} else if hours < 24*30*2 {
+		return hours / 24 / 7, DurationWeek
+	} else if hours < 24*365*2 {
+		return hours / 24 / 30, DurationMonth
+	}
+	return int(d.Hours()) / 24 / 365, DurationYear
+}
+
 // HumanDuration returns a human-readable approximation of a duration
 // (eg. "About a minute", "4 hours ago", etc.).
 func HumanDuration(d time.Duration) string {
-	if seconds := int(d.Seconds()); seconds < 1 {
+	n, unit := ApproxDuration(d)
+	switch {
+	case n == 0 && unit == DurationSecond:
 		return "Less than a second"
-	} else if seconds == 1 {
+	case n == 1 && unit == DurationSecond:
 		return "1 second"
-	} else if seconds < 60 {
-		return fmt.Sprintf("%d seconds", seconds)
-	} else if minutes := int(d.Minutes()); minutes == 1 {
+	case n == 1 && unit == DurationMinute:
 		return "About a minute"
-	} else if minutes < 60 {
-		return fmt.Sprintf("%d minutes", minutes)
-	} else if hours := int(d.Hours() + 0.5); hours == 1 {
+	case n == 1 && unit == DurationHour:
 		return "About an hour"
-	} else if hours < 48 {
-		return fmt.Sprintf("%d hours", hours)
-	} else if hours < 24*7*2 {
-		return fmt.Sprintf("%d days", hours/24)
-	} else if hours < 24*30*2 {
-		return fmt.Sprintf("%d weeks", hours/24/7)
-	} else if hours < 24*365*2 {
-		return fmt.Sprintf("%d months", hours/24/30)
 	}
-	return fmt.Sprintf("%d years", int(d.Hours())/24/365)
+	return fmt.Sprintf("%d %ss", n, unit)
 }
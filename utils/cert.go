@@ -8,6 +8,9 @@ import (
 )
 
 // CertInfo "test.pem"
+// 只解析第一个 PEM 块
+//
+// Deprecated: use cert.LoadFile or cert.ParsePEM, which read whole bundles.
 func CertInfo(certPath string, certBytes []byte) (*x509.Certificate, error) {
 	var err error
 	if len(certPath) > 0 {
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// ErrCiphertext is returned when a ciphertext has an invalid length or
+// padding, usually because the key is wrong or the data was tampered with.
+var ErrCiphertext = errors.New("utils: invalid ciphertext")
+
+// Cipher encrypts and decrypts byte slices. The CBC ciphers below cover the
+// legacy helpers of this package and exist to read old data: they are not
+// authenticated, new data should use aes.NewGCM.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// legacyDESIV is the IV hardcoded in EncryptDES and DecryptDES.
+var legacyDESIV = []byte("12345678")
+
+// CBCCipher is a block cipher in CBC mode with PKCS#7 padding and a fixed IV.
+type CBCCipher struct {
+	block cipher.Block
+	iv    []byte
+}
+
+var _ Cipher = (*CBCCipher)(nil)
+
+// NewCBCCipher creates a CBCCipher. iv must be one block long.
+func NewCBCCipher(block cipher.Block, iv []byte) (*CBCCipher, error) {
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("utils: iv must be %d bytes", block.BlockSize())
+	}
+	return &CBCCipher{block: block, iv: append([]byte(nil), iv...)}, nil
+}
+
+// NewDESCipher is the cipher of EncryptDES and DecryptDES: DES with an 8
+// byte key and the IV "12345678".
+func NewDESCipher(key []byte) (*CBCCipher, error) {
+	block, err := des.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewCBCCipher(block, legacyDESIV)
+}
+
+// NewTripleDESCipher is the cipher of Encrypt3DES and Decrypt3DES: 3DES with
+// a 24 byte key whose first 8 bytes are the IV.
+func NewTripleDESCipher(key []byte) (*CBCCipher, error) {
+	block, err := des.NewTripleDESCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewCBCCipher(block, key[:block.BlockSize()])
+}
+
+// NewAESCBCCipher is the cipher of AesEncrypt and AesDecrypt, and so of
+// EnTxtByAes without its hex encoding: AES with a 16, 24 or 32 byte key
+// whose first 16 bytes are the IV.
+func NewAESCBCCipher(key []byte) (*CBCCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewCBCCipher(block, key[:block.BlockSize()])
+}
+
+func (c *CBCCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	src := PKCS7Padding(append([]byte(nil), plaintext...), c.block.BlockSize())
+	dst := make([]byte, len(src))
+	cipher.NewCBCEncrypter(c.block, c.iv).CryptBlocks(dst, src)
+	return dst, nil
+}
+
+func (c *CBCCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 || len(ciphertext)%c.block.BlockSize() != 0 {
+		return nil, ErrCiphertext
+	}
+	dst := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(c.block, c.iv).CryptBlocks(dst, ciphertext)
+	return unpad(dst, c.block.BlockSize())
+}
+
+// unpad checks all the padding bytes, in constant time.
+func unpad(src []byte, blockSize int) ([]byte, error) {
+	length := len(src)
+	if length == 0 || length%blockSize != 0 {
+		return nil, ErrCiphertext
+	}
+
+	n := int(src[length-1])
+	good := subtle.ConstantTimeLessOrEq(1, n) & subtle.ConstantTimeLessOrEq(n, blockSize)
+	for i := 1; i <= blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, n)
+		good &= subtle.ConstantTimeByteEq(src[length-i], byte(n)) | (inPadding ^ 1)
+	}
+	if good != 1 {
+		return nil, ErrCiphertext
+	}
+	return src[:length-n], nil
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyCompatibility(t *testing.T) {
+	// what the helpers produced before they were rewritten
+	key := []byte("12345670")
+	block, _ := des.NewCipher(key)
+	src := PKCS7Padding([]byte("今天天气好晴朗"), 8)
+	want := make([]byte, len(src))
+	cipher.NewCBCEncrypter(block, []byte("12345678")).CryptBlocks(want, src)
+	assert.Equal(t, want, EncryptDES([]byte("今天天气好晴朗"), key))
+
+	c, err := NewDESCipher(key)
+	assert.NoError(t, err)
+	dec, err := c.Decrypt(want)
+	assert.NoError(t, err)
+	assert.Equal(t, "今天天气好晴朗", string(dec))
+
+	// EnTxtByAes is hex of the AES-CBC cipher, the format of the crypto-js client
+	enc := EnTxtByAes("root", "lInkbook1qazEWSP")
+	assert.Equal(t, "root", DeTxtByAes(enc, "lInkbook1qazEWSP"))
+	aesCipher, _ := NewAESCBCCipher([]byte("lInkbook1qazEWSP"))
+	raw, _ := hex.DecodeString(enc)
+	dec, err = aesCipher.Decrypt(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, "root", string(dec))
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	for name, newCipher := range map[string]func([]byte) (*CBCCipher, error){
+		"des":  NewDESCipher,
+		"3des": NewTripleDESCipher,
+		"aes":  NewAESCBCCipher,
+	} {
+		var key []byte
+		switch name {
+		case "des":
+			key = []byte("12345678")
+		case "3des":
+			key = []byte("12345678876543211aaaaaaa")
+		case "aes":
+			key = []byte("1234567887654321")
+		}
+		c, err := newCipher(key)
+		assert.NoError(t, err, name)
+		for _, msg := range []string{"", "a", "0123456789abcdef", "root password"} {
+			ct, err := c.Encrypt([]byte(msg))
+			assert.NoError(t, err, name)
+			pt, err := c.Decrypt(ct)
+			assert.NoError(t, err, name)
+			assert.Equal(t, msg, string(pt), name)
+		}
+	}
+}
+
+func TestCipherErrors(t *testing.T) {
+	_, err := NewDESCipher([]byte("short"))
+	assert.Error(t, err)
+	_, err = NewTripleDESCipher([]byte("12345678"))
+	assert.Error(t, err)
+	_, err = NewAESCBCCipher([]byte("xxx"))
+	assert.Error(t, err)
+	assert.Nil(t, EncryptDES([]byte("x"), []byte("short")))
+	assert.Nil(t, Decrypt3DES([]byte("x"), []byte("short")))
+
+	c, _ := NewDESCipher([]byte("12345678"))
+	_, err = c.Decrypt(nil)
+	assert.ErrorIs(t, err, ErrCiphertext)
+	_, err = c.Decrypt([]byte("1234567"))
+	assert.ErrorIs(t, err, ErrCiphertext)
+
+	// src is left untouched
+	ct, _ := c.Encrypt([]byte("secret"))
+	orig := append([]byte(nil), ct...)
+	DecryptDES(ct, []byte("12345678"))
+	assert.Equal(t, orig, ct)
+
+	// a wrong key almost always fails the padding check
+	other, _ := NewDESCipher([]byte("87654321"))
+	failed := 0
+	for i := 0; i < 20; i++ {
+		ct, _ := c.Encrypt([]byte{byte(i)})
+		if _, err := other.Decrypt(ct); err != nil {
+			failed++
+		}
+	}
+	assert.Greater(t, failed, 15)
+}
+
+func TestPKCS7UnPadding(t *testing.T) {
+	out, err := PKCS7UnPadding([]byte{'a', 'b', 2, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ab"), out)
+
+	for _, bad := range [][]byte{nil, {0}, {'a', 5}, {'a', 1, 2}} {
+		_, err := PKCS7UnPadding(bad)
+		assert.ErrorIs(t, err, ErrCiphertext, "%v", bad)
+	}
+
+	_, err = unpad([]byte{1, 2, 3, 4, 5, 6, 7, 9}, 8)
+	assert.ErrorIs(t, err, ErrCiphertext)
+	out, err = unpad([]byte{1, 2, 3, 4, 5, 6, 2, 2}, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6}, out)
+}
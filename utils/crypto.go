@@ -2,11 +2,7 @@ package utils
 
 import (
 	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/des"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"github.com/v-mars/library/lang/conv"
 	"github.com/v-mars/library/logs"
@@ -26,17 +22,10 @@ func PKCS7Padding(ciphertext []byte, blockSize int) []byte {
 }
 
 // PKCS7UnPadding 填充的反向操作，删除填充字符串
+// 填充长度越界或填充字节不一致时返回 ErrCiphertext
 func PKCS7UnPadding(origData []byte) ([]byte, error) {
-	//获取数据长度
-	length := len(origData)
-	if length == 0 {
-		return nil, errors.New("加密字符串错误！")
-	} else {
-		//获取填充字符串长度
-		unpadding := int(origData[length-1])
-		//截取切片，删除填充字节，并且返回明文
-		return origData[:(length - unpadding)], nil
-	}
+	// 分组长度未知，按整段数据检查，填充长度最多 255
+	return unpad(origData, len(origData))
 }
 
 //// 填充最后一个分组的函数
@@ -65,63 +54,60 @@ func PKCS7UnPadding(origData []byte) ([]byte, error) {
 //}
 
 // EncryptDES 使用des进行对称加密 length 8
+// 密钥错误时返回 nil
+//
+// Deprecated: DES is broken and the IV is fixed, use NewDESCipher to read
+// old data, wrapped in an aes.LegacyFunc, and aes.Migrate to move it to
+// AES-GCM.
 func EncryptDES(src []byte, private []byte) []byte {
-	//1、创建并返回一个使用DES算法的cipher.Block接口
-	block, _ := des.NewCipher(private)
-	//2、对最后一个明文分组进行数据填充
-	src = PKCS7Padding(src, block.BlockSize())
-	//3、创建一个密码分组为链接模式的，底层使用DES加密的BlockMode接口
-	iv := []byte("12345678")
-	blockMode := cipher.NewCBCEncrypter(block, iv)
-	//4、加密连续的数据块
-	dst := make([]byte, len(src))
-	blockMode.CryptBlocks(dst, src)
-	return dst
+	return legacyEncrypt(NewDESCipher, src, private)
 }
 
 // DecryptDES 使用des进行解密
+// 密钥或密文错误时返回 nil，src 不会被修改
+//
+// Deprecated: use NewDESCipher, whose Decrypt returns the error.
 func DecryptDES(src, private []byte) []byte {
-	block, err := des.NewCipher(private)
-	if err != nil {
-		print(err)
-	}
-	//2、创建一个密码分组位链接模式的，底层使用DES解密的BlockMode接口
-	iv := []byte("12345678")
-	blockMode := cipher.NewCBCDecrypter(block, iv)
-	//3、数据库解密
-	blockMode.CryptBlocks(src, src)
-	//4、去掉最后一组的填充数据
-	newText, _ := PKCS7UnPadding(src)
-	return newText
+	return legacyDecrypt(NewDESCipher, src, private)
 }
 
 // Encrypt3DES 使用des进行对称加密 length 24
+// 密钥错误时返回 nil
+//
+// Deprecated: the key is used as IV, use NewTripleDESCipher to read old
+// data, wrapped in an aes.LegacyFunc, and aes.Migrate to move it to
+// AES-GCM.
 func Encrypt3DES(src []byte, private []byte) []byte {
-	//1、创建并返回一个使用DES算法的cipher.Block接口
-	block, _ := des.NewTripleDESCipher(private)
-	//2、对最后一个明文分组进行数据填充
-	src = PKCS7Padding(src, block.BlockSize())
-	//3、创建一个密码分组为链接模式的，底层使用DES加密的BlockMode接口
-	blockMode := cipher.NewCBCEncrypter(block, private[:block.BlockSize()])
-	//4、加密连续的数据块
-	dst := make([]byte, len(src))
-	blockMode.CryptBlocks(dst, src)
-	return dst
+	return legacyEncrypt(NewTripleDESCipher, src, private)
 }
 
 // Decrypt3DES 使用des进行解密
+// 密钥或密文错误时返回 nil，src 不会被修改
+//
+// Deprecated: use NewTripleDESCipher, whose Decrypt returns the error.
 func Decrypt3DES(src, private []byte) []byte {
-	block, err := des.NewTripleDESCipher(private)
+	return legacyDecrypt(NewTripleDESCipher, src, private)
+}
+
+func legacyEncrypt(newCipher func([]byte) (*CBCCipher, error), src, key []byte) []byte {
+	c, err := newCipher(key)
 	if err != nil {
-		print(err)
+		return nil
 	}
-	//2、创建一个密码分组位链接模式的，底层使用DES解密的BlockMode接口
-	blockMode := cipher.NewCBCDecrypter(block, private[:block.BlockSize()])
-	//3、数据库解密
-	blockMode.CryptBlocks(src, src)
-	//4、去掉最后一组的填充数据
-	newText, _ := PKCS7UnPadding(src)
-	return newText
+	dst, _ := c.Encrypt(src)
+	return dst
+}
+
+func legacyDecrypt(newCipher func([]byte) (*CBCCipher, error), src, key []byte) []byte {
+	c, err := newCipher(key)
+	if err != nil {
+		return nil
+	}
+	dst, err := c.Decrypt(src)
+	if err != nil {
+		return nil
+	}
+	return dst
 }
 
 //// EncryptAES 使用AES加密 length 16
@@ -280,45 +266,30 @@ func ValidateSaltPasswords(hashedPwd string, plainPwd []byte) bool {
 var DefaultTxtKey = "Airkbook1qaz*WSP"
 
 // AesEncrypt 实现加密
+//
+// Deprecated: the key is used as IV and the ciphertext is not
+// authenticated, use aes.NewGCM. NewAESCBCCipher reads old data.
 func AesEncrypt(origData []byte, key []byte) ([]byte, error) {
-	//创建加密算法实例
-	block, err := aes.NewCipher(key)
+	c, err := NewAESCBCCipher(key)
 	if err != nil {
 		return nil, err
 	}
-	//获取块的大小
-	blockSize := block.BlockSize()
-	//对数据进行填充，让数据长度满足需求
-	origData = PKCS7Padding(origData, blockSize)
-	//采用AES加密方法中CBC加密模式
-	blocMode := cipher.NewCBCEncrypter(block, key[:blockSize])
-	crypted := make([]byte, len(origData))
-	//执行加密
-	blocMode.CryptBlocks(crypted, origData)
-	return crypted, nil
+	return c.Encrypt(origData)
 }
 
 // AesDecrypt 实现解密
+//
+// Deprecated: see AesEncrypt.
 func AesDecrypt(cypted []byte, key []byte) (string, error) {
-	//创建加密算法实例
-	block, err := aes.NewCipher(key)
+	c, err := NewAESCBCCipher(key)
 	if err != nil {
 		return "", err
 	}
-	//获取块大小
-	blockSize := block.BlockSize()
-	//创建加密客户端实例
-	//blockMode := cipher.NewCBCDecrypter(block, key)
-	blockMode := cipher.NewCBCDecrypter(block, key[:blockSize])
-	origData := make([]byte, len(cypted))
-	//这个函数也可以用来解密
-	blockMode.CryptBlocks(origData, cypted)
-	//去除填充字符串
-	origData, err = PKCS7UnPadding(origData)
+	origData, err := c.Decrypt(cypted)
 	if err != nil {
 		return "", err
 	}
-	return string(origData), err
+	return string(origData), nil
 }
 
 // EnTxtByAes 加密base64
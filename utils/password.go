@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+const (
+	LowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	UpperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	SymbolChars = "!#$%&*+-=?@^_~"
+
+	// AmbiguousChars look alike in many fonts.
+	AmbiguousChars = "0O1lI|"
+)
+
+// CharClass is a set of character classes.
+type CharClass uint8
+
+const (
+	ClassLower CharClass = 1 << iota
+	ClassUpper
+	ClassDigit
+	ClassSymbol
+
+	ClassAll = ClassLower | ClassUpper | ClassDigit | ClassSymbol
+)
+
+// PasswordPolicy describes the passwords made by GeneratePassword.
+type PasswordPolicy struct {
+	// Length of the password, 16 when zero.
+	Length int
+	// Classes the characters are drawn from, ClassAll when zero.
+	Classes CharClass
+	// Require lists the classes that appear at least once.
+	Require CharClass
+	// Symbols overrides SymbolChars.
+	Symbols string
+	// ExcludeAmbiguous removes AmbiguousChars.
+	ExcludeAmbiguous bool
+	// Exclude removes more characters, for example quotes.
+	Exclude string
+}
+
+// DefaultPasswordPolicy is 16 characters of all classes, each class at least
+// once, without ambiguous characters: about 98 bits of entropy.
+var DefaultPasswordPolicy = PasswordPolicy{
+	Length:           16,
+	Classes:          ClassAll,
+	Require:          ClassAll,
+	ExcludeAmbiguous: true,
+}
+
+// PasswordEntropy reports the strength of a PasswordPolicy.
+type PasswordEntropy struct {
+	Length   int
+	PoolSize int
+	// Bits is log2 of the number of passwords the policy can produce.
+	Bits float64
+}
+
+func (e PasswordEntropy) String() string {
+	return fmt.Sprintf("%d chars from %d, %.1f bits", e.Length, e.PoolSize, e.Bits)
+}
+
+type charPool struct {
+	chars   []rune
+	classes []int // index of the class of each char
+	require []int // indexes of the required classes
+	sizes   []int // number of chars of each class
+}
+
+var classOrder = []CharClass{ClassLower, ClassUpper, ClassDigit, ClassSymbol}
+
+func (p PasswordPolicy) normalize() PasswordPolicy {
+	if p.Length == 0 {
+		p.Length = 16
+	}
+	if p.Classes == 0 {
+		p.Classes = ClassAll
+	}
+	if p.Symbols == "" {
+		p.Symbols = SymbolChars
+	}
+	return p
+}
+
+func (p PasswordPolicy) pool() (*charPool, error) {
+	if p.Length < 0 {
+		return nil, errors.New("utils: password length is negative")
+	}
+	if p.Require&^p.Classes != 0 {
+		return nil, errors.New("utils: password requires a class it does not use")
+	}
+
+	exclude := p.Exclude
+	if p.ExcludeAmbiguous {
+		exclude += AmbiguousChars
+	}
+
+	pool := &charPool{sizes: make([]int, len(classOrder))}
+	seen := map[rune]bool{}
+	for ci, class := range classOrder {
+		if p.Classes&class == 0 {
+			continue
+		}
+
+		var chars string
+		switch class {
+		case ClassLower:
+			chars = LowerChars
+		case ClassUpper:
+			chars = UpperChars
+		case ClassDigit:
+			chars = DecChars
+		case ClassSymbol:
+			chars = p.Symbols
+		}
+		for _, c := range chars {
+			if seen[c] || strings.ContainsRune(exclude, c) {
+				continue
+			}
+			seen[c] = true
+			pool.chars = append(pool.chars, c)
+			pool.classes = append(pool.classes, ci)
+			pool.sizes[ci]++
+		}
+
+		if p.Require&class != 0 {
+			if pool.sizes[ci] == 0 {
+				return nil, fmt.Errorf("utils: password class %d has no characters left", class)
+			}
+			pool.require = append(pool.require, ci)
+		}
+	}
+
+	if len(pool.chars) == 0 {
+		return nil, errors.New("utils: password policy has no characters")
+	}
+	if p.Length < len(pool.require) {
+		return nil, fmt.Errorf("utils: password length %d is less than the %d required classes", p.Length, len(pool.require))
+	}
+	return pool, nil
+}
+
+// GeneratePassword generates a random password following policy. Every
+// password allowed by the policy is equally likely, so its strength is
+// exactly the one reported by policy.Entropy.
+func GeneratePassword(policy PasswordPolicy) (string, error) {
+	policy = policy.normalize()
+	pool, err := policy.pool()
+	if err != nil {
+		return "", err
+	}
+
+	idx := make([]int, policy.Length)
+	for {
+		randIndexes(idx, len(pool.chars), cryptoRead)
+		if pool.satisfied(idx) {
+			return pick(pool.chars, idx), nil
+		}
+	}
+}
+
+// satisfied reports whether idx contains every required class. Retrying
+// until it does, rather than placing the required characters at random
+// positions, keeps the distribution uniform.
+func (p *charPool) satisfied(idx []int) bool {
+	var found [4]bool
+	for _, i := range idx {
+		found[p.classes[i]] = true
+	}
+	for _, ci := range p.require {
+		if !found[ci] {
+			return false
+		}
+	}
+	return true
+}
+
+// Entropy reports the strength of the passwords generated with p.
+func (p PasswordPolicy) Entropy() (PasswordEntropy, error) {
+	p = p.normalize()
+	pool, err := p.pool()
+	if err != nil {
+		return PasswordEntropy{}, err
+	}
+
+	// count the passwords containing every required class by
+	// inclusion-exclusion over the subsets of classes left out
+	count := new(big.Int)
+	term := new(big.Int)
+	length := big.NewInt(int64(p.Length))
+	for subset := 0; subset < 1<<len(pool.require); subset++ {
+		size, sign := len(pool.chars), 1
+		for i, ci := range pool.require {
+			if subset&(1<<i) != 0 {
+				size -= pool.sizes[ci]
+				sign = -sign
+			}
+		}
+		term.Exp(big.NewInt(int64(size)), length, nil)
+		if sign > 0 {
+			count.Add(count, term)
+		} else {
+			count.Sub(count, term)
+		}
+	}
+
+	return PasswordEntropy{
+		Length:   p.Length,
+		PoolSize: len(pool.chars),
+		Bits:     log2(count),
+	}, nil
+}
+
+func log2(x *big.Int) float64 {
+	if x.Sign() <= 0 {
+		return 0
+	}
+	// keep the top 64 bits for the mantissa
+	shift := max(x.BitLen()-64, 0)
+	top, _ := new(big.Float).SetInt(new(big.Int).Rsh(x, uint(shift))).Float64()
+	return math.Log2(top) + float64(shift)
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		pw, err := GeneratePassword(DefaultPasswordPolicy)
+		assert.NoError(t, err)
+		assert.Len(t, pw, 16)
+		assert.False(t, strings.ContainsAny(pw, AmbiguousChars))
+		assert.True(t, strings.ContainsAny(pw, LowerChars))
+		assert.True(t, strings.ContainsAny(pw, UpperChars))
+		assert.True(t, strings.ContainsAny(pw, DecChars))
+		assert.True(t, strings.ContainsAny(pw, SymbolChars))
+	}
+
+	pw, err := GeneratePassword(PasswordPolicy{Length: 4, Classes: ClassDigit, Require: ClassDigit})
+	assert.NoError(t, err)
+	assert.Len(t, pw, 4)
+	assert.Empty(t, strings.Trim(pw, DecChars))
+
+	pw, err = GeneratePassword(PasswordPolicy{Classes: ClassLower | ClassSymbol, Symbols: "-_", Exclude: "aeiou"})
+	assert.NoError(t, err)
+	assert.Len(t, pw, 16)
+	assert.False(t, strings.ContainsAny(pw, "aeiou"+UpperChars+DecChars))
+}
+
+func TestGeneratePasswordInvalid(t *testing.T) {
+	for _, p := range []PasswordPolicy{
+		{Length: -1},
+		{Length: 3, Require: ClassAll},
+		{Classes: ClassDigit, Require: ClassLower},
+		{Classes: ClassDigit, Require: ClassDigit, Exclude: DecChars},
+	} {
+		_, err := GeneratePassword(p)
+		assert.Error(t, err, "%+v", p)
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	e, err := PasswordPolicy{Length: 10, Classes: ClassDigit}.Entropy()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, e.PoolSize)
+	assert.InDelta(t, 10*math.Log2(10), e.Bits, 1e-9)
+
+	// 2 letters, both required: 2^n - 2 passwords
+	e, _ = PasswordPolicy{Length: 3, Classes: ClassDigit | ClassLower, Require: ClassDigit | ClassLower, Exclude: "23456789bcdefghijklmnopqrstuvwxyz"}.Entropy()
+	assert.Equal(t, 3, e.PoolSize)
+	// 0, 1, a: 27 - 2^3 (no digit) - 1 (no letter)
+	assert.InDelta(t, math.Log2(18), e.Bits, 1e-9)
+
+	e, err = DefaultPasswordPolicy.Entropy()
+	assert.NoError(t, err)
+	assert.Equal(t, 71, e.PoolSize)
+	assert.InDelta(t, 98.1, e.Bits, 0.05)
+	t.Log(e)
+}
+
+func BenchmarkGeneratePassword(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = GeneratePassword(DefaultPasswordPolicy)
+	}
+}
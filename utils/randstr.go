@@ -1,9 +1,11 @@
 package utils
 
 import (
-	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"math/bits"
+	mrand "math/rand/v2"
+	"strings"
 )
 
 // Bytes generates n random bytes
@@ -39,21 +41,105 @@ func Hex(n int) string { return RandStr(n, HexChars) }
 var defLetters = []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
 // String generates a random string using only letters provided in the letters parameter
-// if user ommit letters parameters, this function will use defLetters instead
+// if user ommit letters parameters, this function will use defLetters instead.
+// The letters are drawn from crypto/rand without modulo bias, so the result
+// is suitable for tokens, API keys and passwords.
 func RandStr(n int, letters ...string) string {
-	var letterRunes []rune
-	if len(letters) == 0 {
-		letterRunes = defLetters
-	} else {
-		letterRunes = []rune(letters[0])
+	if n <= 0 {
+		return ""
 	}
+	letterRunes := alphabet(letters)
+	idx := make([]int, n)
+	randIndexes(idx, len(letterRunes), cryptoRead)
+	return pick(letterRunes, idx)
+}
+
+// FastRandStr is like RandStr but uses math/rand/v2, which is much faster and
+// never blocks. Do not use it for secrets.
+func FastRandStr(n int, letters ...string) string {
+	if n <= 0 {
+		return ""
+	}
+	letterRunes := alphabet(letters)
+	idx := make([]int, n)
+	randIndexes(idx, len(letterRunes), fastRead)
+	return pick(letterRunes, idx)
+}
+
+func alphabet(letters []string) []rune {
+	if len(letters) == 0 || letters[0] == "" {
+		return defLetters
+	}
+	return []rune(letters[0])
+}
+
+func pick(letterRunes []rune, idx []int) string {
+	var sb strings.Builder
+	sb.Grow(len(idx))
+	for _, i := range idx {
+		sb.WriteRune(letterRunes[i])
+	}
+	return sb.String()
+}
+
+func cryptoRead(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+func fastRead(b []byte) {
+	for len(b) >= 8 {
+		binary.LittleEndian.PutUint64(b, mrand.Uint64())
+		b = b[8:]
+	}
+	if len(b) > 0 {
+		var tail [8]byte
+		binary.LittleEndian.PutUint64(tail[:], mrand.Uint64())
+		copy(b, tail[:])
+	}
+}
+
+// randIndexes fills idx with uniform random numbers in [0, l) drawn from
+// read. Random bytes are read in bulk and masked to the smallest power of
+// two covering l; values >= l are rejected instead of reduced
+// modulo l, which would favour the first letters of the alphabet.
+func randIndexes(idx []int, l int, read func([]byte)) {
+	if len(idx) == 0 {
+		return
+	}
+	if l <= 1 {
+		clear(idx)
+		return
+	}
+
+	width := 1 // bytes per sample
+	if l > 1<<8 {
+		width = 4
+	}
+	mask := uint32(1)<<bits.Len32(uint32(l-1)) - 1
+
+	// on average less than 2 samples are drawn per index, read a little
+	// more than that at once and refill when it runs out
+	buf := make([]byte, (len(idx)+len(idx)/4+8)*width)
+	pos := len(buf)
+	for i := 0; i < len(idx); {
+		if pos+width > len(buf) {
+			read(buf)
+			pos = 0
+		}
+
+		var v uint32
+		if width == 1 {
+			v = uint32(buf[pos])
+		} else {
+			v = binary.BigEndian.Uint32(buf[pos:])
+		}
+		pos += width
 
-	var bb bytes.Buffer
-	bb.Grow(n)
-	l := uint32(len(letterRunes))
-	// on each loop, generate one random rune and append to output
-	for i := 0; i < n; i++ {
-		bb.WriteRune(letterRunes[binary.BigEndian.Uint32(Bytes(4))%l])
+		if v &= mask; v < uint32(l) {
+			idx[i] = int(v)
+			i++
+		}
 	}
-	return bb.String()
 }
@@ -2,6 +2,11 @@ package utils
 
 import (
 	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func ExampleHex() {
@@ -42,3 +47,60 @@ func ExampleDec() {
 	//0271037110897873
 	//0337735480322223
 }
+
+func TestRandStr(t *testing.T) {
+	for _, letters := range []string{"", DecChars, Base64Chars, "中文字符", "ab"} {
+		s := RandStr(64, letters)
+		assert.Equal(t, 64, utf8.RuneCountInString(s))
+		if letters == "" {
+			letters = Base62Chars
+		}
+		for _, c := range s {
+			assert.True(t, strings.ContainsRune(letters, c), "%q not in %q", c, letters)
+		}
+	}
+
+	assert.Equal(t, "", RandStr(0))
+	assert.Equal(t, "", RandStr(-1))
+	assert.Equal(t, "", FastRandStr(-1))
+	assert.Equal(t, "xxx", RandStr(3, "x"))
+	assert.Len(t, FastRandStr(32, HexChars), 32)
+	assert.NotEqual(t, RandStr(32), RandStr(32))
+}
+
+func TestRandIndexesUniform(t *testing.T) {
+	// 62 letters is the worst case for modulo bias on one byte: the first
+	// 8 letters would be drawn 5/4 as often as the others
+	const n, l = 620000, 62
+	idx := make([]int, n)
+	randIndexes(idx, l, cryptoRead)
+
+	counts := make([]int, l)
+	for _, i := range idx {
+		counts[i]++
+	}
+	for i, c := range counts {
+		assert.InDelta(t, n/l, c, n/l*0.05, "letter %d", i)
+	}
+
+	// alphabets wider than a byte
+	idx = make([]int, 1000)
+	randIndexes(idx, 1000, fastRead)
+	for _, i := range idx {
+		assert.True(t, i >= 0 && i < 1000)
+	}
+}
+
+func BenchmarkRandStr(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		RandStr(32)
+	}
+}
+
+func BenchmarkFastRandStr(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		FastRandStr(32)
+	}
+}
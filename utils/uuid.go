@@ -1,19 +1,27 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/md5"
 	"crypto/rand"
 	"crypto/sha1"
+	"database/sql/driver"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"hash"
 	"io"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Nil empty UUID, all zeros
 var Nil UUID
 
 // A UUID is a 128 bit (16 byte) Universal Unique IDentifier as defined in RFC
-// 4122.
+// 9562 (formerly RFC 4122).
 type UUID [16]byte
 
 // Bytes returns bytes slice representation of UUID.
@@ -103,3 +111,195 @@ func NewMD5(space UUID, data []byte) UUID {
 func NewSHA1(space UUID, data []byte) UUID {
 	return NewHash(sha1.New(), space, data, 5)
 }
+
+// NewV7 returns a time-ordered (Version 7) UUID: a 48 bit Unix timestamp in
+// milliseconds followed by random bits. UUIDs generated by this process are
+// strictly increasing, even within the same millisecond, so they make
+// index-friendly database keys.
+//
+// The 12 bit rand_a field holds a counter seeded randomly every millisecond
+// (RFC 9562 section 6.2, method 1). If it overflows, or the clock goes
+// backwards, the timestamp of the previous UUID is advanced instead.
+func NewV7() (UUID, error) {
+	var uuid UUID
+	if _, err := io.ReadFull(rand.Reader, uuid[6:]); err != nil {
+		return Nil, err
+	}
+
+	v7Mu.Lock()
+	ms := uint64(time.Now().UnixMilli())
+	seq := uint16(uuid[6])<<8 | uint16(uuid[7])
+	if ms > v7LastMs {
+		// keep the top bit clear to leave room for increments
+		seq &= 0x07ff
+	} else {
+		ms = v7LastMs
+		seq = v7LastSeq + 1
+		if seq > 0x0fff {
+			ms++
+			seq &= 0x07ff
+		}
+	}
+	v7LastMs, v7LastSeq = ms, seq
+	v7Mu.Unlock()
+
+	uuid[0] = byte(ms >> 40)
+	uuid[1] = byte(ms >> 32)
+	uuid[2] = byte(ms >> 24)
+	uuid[3] = byte(ms >> 16)
+	uuid[4] = byte(ms >> 8)
+	uuid[5] = byte(ms)
+	uuid[6] = 0x70 | byte(seq>>8) // Version 7
+	uuid[7] = byte(seq)
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // Variant is 10
+	return uuid, nil
+}
+
+var (
+	v7Mu      sync.Mutex
+	v7LastMs  uint64
+	v7LastSeq uint16
+)
+
+// Version returns the version of uuid, the high 4 bits of its 7th byte.
+func (uuid UUID) Version() int {
+	return int(uuid[6] >> 4)
+}
+
+// Timestamp returns the creation time embedded in a Version 1, 6 or 7 UUID,
+// and false for the other versions.
+func (uuid UUID) Timestamp() (time.Time, bool) {
+	switch uuid.Version() {
+	case 7:
+		ms := int64(binary.BigEndian.Uint64(uuid[:8]) >> 16)
+		return time.UnixMilli(ms), true
+	case 1:
+		ts := uint64(binary.BigEndian.Uint16(uuid[6:8])&0x0fff)<<48 |
+			uint64(binary.BigEndian.Uint16(uuid[4:6]))<<32 |
+			uint64(binary.BigEndian.Uint32(uuid[0:4]))
+		return gregorianTime(ts), true
+	case 6:
+		ts := binary.BigEndian.Uint64(uuid[:8])
+		ts = ts>>16<<12 | ts&0x0fff
+		return gregorianTime(ts), true
+	}
+	return time.Time{}, false
+}
+
+// gregorianTime converts a count of 100ns intervals since 1582-10-15.
+func gregorianTime(ts uint64) time.Time {
+	const gregorianToUnix = 122192928000000000
+	t := int64(ts) - gregorianToUnix
+	return time.Unix(t/1e7, t%1e7*100)
+}
+
+// Compare returns -1, 0 or +1 comparing a and b byte by byte. For Version 7
+// UUIDs this is the order of creation, and it matches the order of their
+// binary and string forms.
+func Compare(a, b UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// Parse decodes s into a UUID. The forms accepted are
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx, the same wrapped in braces or
+// prefixed with urn:uuid:, and 32 hex digits without hyphens.
+func Parse(s string) (UUID, error) {
+	var uuid UUID
+	switch len(s) {
+	case 36:
+	case 36 + 9:
+		if !strings.EqualFold(s[:9], "urn:uuid:") {
+			return Nil, fmt.Errorf("invalid urn prefix: %q", s[:9])
+		}
+		s = s[9:]
+	case 36 + 2:
+		if s[0] != '{' || s[37] != '}' {
+			return Nil, errors.New("invalid bracketed UUID format")
+		}
+		s = s[1:37]
+	case 32:
+		if _, err := hex.Decode(uuid[:], []byte(s)); err != nil {
+			return Nil, errors.New("invalid UUID format")
+		}
+		return uuid, nil
+	default:
+		return Nil, fmt.Errorf("invalid UUID length: %d", len(s))
+	}
+
+	if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return Nil, errors.New("invalid UUID format")
+	}
+	for i, x := range [16]int{0, 2, 4, 6, 9, 11, 14, 16, 19, 21, 24, 26, 28, 30, 32, 34} {
+		if _, err := hex.Decode(uuid[i:i+1], []byte(s[x:x+2])); err != nil {
+			return Nil, errors.New("invalid UUID format")
+		}
+	}
+	return uuid, nil
+}
+
+// MustParse is like Parse but panics if s cannot be parsed.
+func MustParse(s string) UUID {
+	return Must(Parse(s))
+}
+
+// MarshalText implements encoding.TextMarshaler, and so JSON marshalling.
+func (uuid UUID) MarshalText() ([]byte, error) {
+	var buf [36]byte
+	encodeHex(buf[:], uuid)
+	return buf[:], nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (uuid *UUID) UnmarshalText(data []byte) error {
+	id, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*uuid = id
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (uuid UUID) MarshalBinary() ([]byte, error) {
+	return uuid[:], nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (uuid *UUID) UnmarshalBinary(data []byte) error {
+	if len(data) != 16 {
+		return fmt.Errorf("invalid UUID (got %d bytes)", len(data))
+	}
+	copy(uuid[:], data)
+	return nil
+}
+
+// Scan implements sql.Scanner. It accepts the string forms of Parse and
+// 16 byte binary values; NULL and empty values scan into Nil.
+func (uuid *UUID) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*uuid = Nil
+		return nil
+	case string:
+		if src == "" {
+			*uuid = Nil
+			return nil
+		}
+		return uuid.UnmarshalText([]byte(src))
+	case []byte:
+		switch len(src) {
+		case 0:
+			*uuid = Nil
+			return nil
+		case 16:
+			return uuid.UnmarshalBinary(src)
+		}
+		return uuid.UnmarshalText(src)
+	}
+	return fmt.Errorf("unable to scan type %T into UUID", src)
+}
+
+// Value implements driver.Valuer, storing the UUID as its string form.
+func (uuid UUID) Value() (driver.Value, error) {
+	return uuid.String(), nil
+}
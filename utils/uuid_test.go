@@ -1,10 +1,99 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewMD5(t *testing.T) {
 	fmt.Println(NewMD5(UUID{16}, []byte("1233")))
 }
+
+func TestNewV7(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	prev := Must(NewV7())
+	for i := 0; i < 10000; i++ {
+		id := Must(NewV7())
+		if Compare(prev, id) >= 0 {
+			t.Fatalf("%s is not after %s", id, prev)
+		}
+		if id.String() <= prev.String() {
+			t.Fatalf("string of %s does not sort after %s", id, prev)
+		}
+		prev = id
+	}
+
+	assert.Equal(t, 7, prev.Version())
+	assert.Equal(t, byte(0x80), prev[8]&0xc0)
+	ts, ok := prev.Timestamp()
+	assert.True(t, ok)
+	assert.False(t, ts.Before(before))
+	assert.WithinDuration(t, time.Now(), ts, time.Second)
+}
+
+func TestParse(t *testing.T) {
+	const s = "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"
+	id, err := Parse(s)
+	assert.NoError(t, err)
+	assert.Equal(t, s, id.String())
+	assert.Equal(t, 1, id.Version())
+
+	for _, form := range []string{"urn:uuid:" + s, "{" + s + "}", strings.ReplaceAll(s, "-", ""), strings.ToUpper(s)} {
+		got, err := Parse(form)
+		assert.NoError(t, err, form)
+		assert.Equal(t, id, got, form)
+	}
+	for _, bad := range []string{"", "f81d4fae-7dec-11d0-a765-00a0c91e6bf", "f81d4fae+7dec-11d0-a765-00a0c91e6bf6", "g81d4fae-7dec-11d0-a765-00a0c91e6bf6"} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+	assert.Panics(t, func() { MustParse("bad") })
+
+	// RFC 9562 appendix A test vectors
+	ts, ok := MustParse("C232AB00-9414-11EC-B3C8-9F6BDECED846").Timestamp()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 2, 22, 19, 22, 22, 0, time.UTC), ts.UTC())
+	ts, ok = MustParse("1EC9414C-232A-6B00-B3C8-9F6BDECED846").Timestamp()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 2, 22, 19, 22, 22, 0, time.UTC), ts.UTC())
+	ts, ok = MustParse("017F22E2-79B0-7CC3-98C4-DC0C0C07398F").Timestamp()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 2, 22, 19, 22, 22, 0, time.UTC), ts.UTC())
+	_, ok = Must(NewRandom()).Timestamp()
+	assert.False(t, ok)
+}
+
+func TestUUIDMarshal(t *testing.T) {
+	type row struct {
+		ID UUID `json:"id"`
+	}
+	id := Must(NewV7())
+	b, err := json.Marshal(row{ID: id})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":"`+id.String()+`"}`, string(b))
+
+	var r row
+	assert.NoError(t, json.Unmarshal(b, &r))
+	assert.Equal(t, id, r.ID)
+
+	bin, _ := id.MarshalBinary()
+	var fromBin UUID
+	assert.NoError(t, fromBin.UnmarshalBinary(bin))
+	assert.Equal(t, id, fromBin)
+
+	v, err := id.Value()
+	assert.NoError(t, err)
+	var scanned UUID
+	assert.NoError(t, scanned.Scan(v))
+	assert.Equal(t, id, scanned)
+	assert.NoError(t, scanned.Scan(bin))
+	assert.Equal(t, id, scanned)
+	assert.NoError(t, scanned.Scan(nil))
+	assert.Equal(t, Nil, scanned)
+	assert.Error(t, scanned.Scan(42))
+}